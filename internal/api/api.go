@@ -0,0 +1,681 @@
+package api
+
+import (
+	"coursework/internal/config"
+	"coursework/internal/models"
+	"coursework/internal/storage"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type server struct {
+	store storage.Store
+	// defaults are the parameters of reports requested without them.
+	defaults config.Reports
+}
+
+// NewHandler returns the JSON API over store. Order listings are paged with
+// cursors passed back in the Link header:
+//
+//	GET    /orders?limit=N&from&to&type&currency&min-amount&max-amount&uah&sort&desc&after|before
+//	GET    /orders/{id}
+//	POST   /orders
+//	PATCH  /orders/{id}
+//	DELETE /orders/{id}
+//	GET    /orders/deleted
+//	POST   /orders/{id}/restore
+//	DELETE /orders/deleted?before=RFC3339
+//	GET    /orders/{id}/history
+//	GET    /history?from=RFC3339[&to=RFC3339]
+//	GET    /order-types[?all=true], the codes orders may use
+//	GET    /reports/biggest-dates?limit=N
+//	GET    /reports/rate-changes[?source=official]
+//	GET    /reports/small-orders?type=T&threshold=X, orders per month of exactly type T
+//	GET    /reports/smallest-types?limit=N
+//	GET    /reports/periods?bucket-minutes=N|shift=name=HH:MM-HH:MM...&tz=ZONE&big-sale=X
+//	GET    /reports/revenue?by=day|week|month
+//	GET    /reports/breakdown
+//
+// Every report also takes from and to, RFC 3339 times bounding the orders it
+// looks at. Parameters left out are taken from defaults.
+func NewHandler(store storage.Store, defaults config.Reports) http.Handler {
+	s := &server{store: store, defaults: defaults}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders", s.listOrders)
+	mux.HandleFunc("GET /orders/{id}", s.getOrder)
+	mux.HandleFunc("POST /orders", s.addOrder)
+	mux.HandleFunc("PATCH /orders/{id}", s.updateOrder)
+	mux.HandleFunc("DELETE /orders/{id}", s.deleteOrder)
+	mux.HandleFunc("GET /orders/deleted", s.deletedOrders)
+	mux.HandleFunc("POST /orders/{id}/restore", s.restoreOrder)
+	mux.HandleFunc("DELETE /orders/deleted", s.purgeOrders)
+	mux.HandleFunc("GET /orders/{id}/history", s.orderHistory)
+	mux.HandleFunc("GET /history", s.changesBetween)
+	mux.HandleFunc("GET /order-types", s.orderTypes)
+
+	mux.HandleFunc("GET /reports/biggest-dates", s.biggestDates)
+	mux.HandleFunc("GET /reports/rate-changes", s.rateChanges)
+	mux.HandleFunc("GET /reports/small-orders", s.smallOrders)
+	mux.HandleFunc("GET /reports/smallest-types", s.smallestTypes)
+	mux.HandleFunc("GET /reports/periods", s.periods)
+	mux.HandleFunc("GET /reports/revenue", s.revenue)
+	mux.HandleFunc("GET /reports/breakdown", s.breakdown)
+
+	return mux
+}
+
+type newOrderRequest struct {
+	TimeStamp    time.Time       `json:"timestamp"`
+	Type         string          `json:"type"`
+	Amount       decimal.Decimal `json:"amount"`
+	Currency     string          `json:"currency"`
+	ExchangeRate decimal.Decimal `json:"exchangeRate"`
+}
+
+type purgeResponse struct {
+	Purged int `json:"purged"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+	// Fields lists the invalid fields of a rejected request.
+	Fields []storage.FieldError `json:"fields,omitempty"`
+}
+
+func (s *server) listOrders(w http.ResponseWriter, r *http.Request) {
+	query, err := orderQueryParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := s.store.ListOrders(r.Context(), query)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	if page.Next != "" {
+		w.Header().Add("Link", pageLink(r, "after", page.Next, "next"))
+	}
+	if page.Prev != "" {
+		w.Header().Add("Link", pageLink(r, "before", page.Prev, "prev"))
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(page.Orders))
+}
+
+// orderQueryParams reads the filter, sort and paging parameters of /orders.
+func orderQueryParams(r *http.Request) (models.OrderQuery, error) {
+	var query models.OrderQuery
+	var errs []error
+	values := r.URL.Query()
+	f := &query.Filter
+
+	var err error
+	if query.Limit, err = intParam(r, "limit", 0); err != nil {
+		errs = append(errs, err)
+	}
+	if f.From, err = timeParam(r, "from", time.Time{}); err != nil {
+		errs = append(errs, err)
+	}
+	if f.To, err = timeParam(r, "to", time.Time{}); err != nil {
+		errs = append(errs, err)
+	}
+	f.Type = values.Get("type")
+	f.Currency = values.Get("currency")
+
+	for _, bound := range []struct {
+		name string
+		dest **decimal.Decimal
+	}{{"min-amount", &f.MinAmount}, {"max-amount", &f.MaxAmount}} {
+		if values.Get(bound.name) == "" {
+			continue
+		}
+		amount, err := decimalParam(r, bound.name, decimal.Zero)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		*bound.dest = &amount
+	}
+
+	if f.InUah, err = boolParam(r, "uah"); err != nil {
+		errs = append(errs, err)
+	}
+	if query.Desc, err = boolParam(r, "desc"); err != nil {
+		errs = append(errs, err)
+	}
+	if raw := values.Get("sort"); raw != "" {
+		if query.Sort, err = models.ParseSortField(raw); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, param := range []struct {
+		name string
+		dest **models.Cursor
+	}{{"after", &query.After}, {"before", &query.Before}} {
+		if values.Get(param.name) == "" {
+			continue
+		}
+		cursor, err := models.DecodeCursor(values.Get(param.name))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		*param.dest = &cursor
+	}
+
+	if err = errors.Join(errs...); err != nil {
+		return query, err
+	}
+	return query, query.Validate()
+}
+
+// pageLink points to the neighbouring page with the same filter, in the
+// format of RFC 8288.
+func pageLink(r *http.Request, param, cursor, rel string) string {
+	values := r.URL.Query()
+	values.Del("after")
+	values.Del("before")
+	values.Set(param, cursor)
+
+	link := url.URL{Path: r.URL.Path, RawQuery: values.Encode()}
+	return fmt.Sprintf("<%s>; rel=%q", link.String(), rel)
+}
+
+func (s *server) getOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid order id: %w", err))
+		return
+	}
+
+	order, err := s.store.GetOrder(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+func (s *server) addOrder(w http.ResponseWriter, r *http.Request) {
+	var req newOrderRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err := s.store.AddNewOrder(r.Context(), req.TimeStamp, req.Type, req.Amount, req.Currency, req.ExchangeRate)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *server) updateOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid order id: %w", err))
+		return
+	}
+
+	var patch models.OrderPatch
+	if err = decodeBody(r, &patch); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = s.store.PatchOrder(r.Context(), id, patch); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) deleteOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid order id: %w", err))
+		return
+	}
+
+	if err = s.store.DeleteOrder(r.Context(), id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) deletedOrders(w http.ResponseWriter, r *http.Request) {
+	orders, err := s.store.DeletedOrders(r.Context())
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(orders))
+}
+
+func (s *server) restoreOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid order id: %w", err))
+		return
+	}
+
+	if err = s.store.RestoreOrder(r.Context(), id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// purgeOrders removes orders deleted before the required "before" time.
+func (s *server) purgeOrders(w http.ResponseWriter, r *http.Request) {
+	before, err := timeParam(r, "before", time.Time{})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if before.IsZero() {
+		writeError(w, http.StatusBadRequest, errors.New("before is required"))
+		return
+	}
+
+	purged, err := s.store.PurgeDeletedOrders(r.Context(), before)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, purgeResponse{Purged: purged})
+}
+
+func (s *server) orderHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid order id: %w", err))
+		return
+	}
+
+	changes, err := s.store.OrderHistory(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if len(changes) == 0 {
+		writeStoreError(w, fmt.Errorf("no history for order %d: %w", id, storage.ErrOrderNotFound))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, changes)
+}
+
+func (s *server) changesBetween(w http.ResponseWriter, r *http.Request) {
+	from, err := timeParam(r, "from", time.Time{})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if from.IsZero() {
+		writeError(w, http.StatusBadRequest, errors.New("from is required"))
+		return
+	}
+
+	to, err := timeParam(r, "to", time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	changes, err := s.store.ChangesBetween(r.Context(), from, to)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(changes))
+}
+
+// orderTypes lists the active order types, or every one if all is set.
+func (s *server) orderTypes(w http.ResponseWriter, r *http.Request) {
+	all, err := boolParam(r, "all")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	types, err := s.store.OrderTypes(r.Context(), all)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(types))
+}
+
+func (s *server) biggestDates(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r, "limit", s.defaults.BiggestLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	dates, err := dateRangeParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	orders, err := s.store.DatesWithBiggestOrders(r.Context(), limit, dates)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(orders))
+}
+
+func (s *server) rateChanges(w http.ResponseWriter, r *http.Request) {
+	dates, err := dateRangeParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var orders []models.Order
+	switch source := r.URL.Query().Get("source"); source {
+	case "", "orders":
+		orders, err = s.store.OrdersWhenRateChanged(r.Context(), dates)
+	case "official":
+		orders, err = s.store.OrdersWhenOfficialRateChanged(r.Context(), dates)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("source must be orders or official, got %q", source))
+		return
+	}
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(orders))
+}
+
+func (s *server) smallOrders(w http.ResponseWriter, r *http.Request) {
+	orderType := r.URL.Query().Get("type")
+	if orderType == "" {
+		orderType = s.defaults.OrderType
+	}
+
+	threshold, err := decimalParam(r, "threshold", s.defaults.LessThan)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	dates, err := dateRangeParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	distribution, err := s.store.MonthlyDistribution(r.Context(), orderType, threshold, dates)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	distribution.Months = nonNil(distribution.Months)
+	writeJSON(w, http.StatusOK, distribution)
+}
+
+func (s *server) smallestTypes(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r, "limit", s.defaults.SmallestLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	dates, err := dateRangeParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	orderTypes, err := s.store.TypeOfSmallestOrders(r.Context(), limit, dates)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(orderTypes))
+}
+
+func (s *server) periods(w http.ResponseWriter, r *http.Request) {
+	buckets := s.defaults.Buckets()
+	var err error
+	if buckets.Minutes, err = intParam(r, "bucket-minutes", buckets.Minutes); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	for _, raw := range r.URL.Query()["shift"] {
+		shift, err := models.ParseShift(raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		buckets.Shifts = append(buckets.Shifts, shift)
+	}
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		if buckets.Location, err = time.LoadLocation(tz); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("tz must be a time zone name, got %q", tz))
+			return
+		}
+	}
+	if buckets.BigSale, err = decimalParam(r, "big-sale", buckets.BigSale); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err = buckets.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	dates, err := dateRangeParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stats, err := s.store.GetTableForPeriods(r.Context(), buckets, dates)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(stats))
+}
+
+func (s *server) revenue(w http.ResponseWriter, r *http.Request) {
+	granularity := s.defaults.Granularity
+	if raw := r.URL.Query().Get("by"); raw != "" {
+		var err error
+		if granularity, err = models.ParseGranularity(raw); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	dates, err := dateRangeParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	points, err := s.store.RevenueSeries(r.Context(), granularity, dates)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(points))
+}
+
+func (s *server) breakdown(w http.ResponseWriter, r *http.Request) {
+	dates, err := dateRangeParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	b, err := s.store.Breakdown(r.Context(), dates)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	b.Currencies, b.Rows, b.Totals = nonNil(b.Currencies), nonNil(b.Rows), nonNil(b.Totals)
+	writeJSON(w, http.StatusOK, b)
+}
+
+func intParam(r *http.Request, name string, fallback int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got %q", name, raw)
+	}
+
+	return value, nil
+}
+
+func boolParam(r *http.Request, name string) (bool, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return false, nil
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false, got %q", name, raw)
+	}
+
+	return value, nil
+}
+
+func decimalParam(r *http.Request, name string, fallback decimal.Decimal) (decimal.Decimal, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	value, err := decimal.NewFromString(raw)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("%s must be a number, got %q", name, raw)
+	}
+
+	return value, nil
+}
+
+func timeParam(r *http.Request, name string, fallback time.Time) (time.Time, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time, got %q", name, raw)
+	}
+
+	return value, nil
+}
+
+// dateRangeParams reads the from and to bounds of a report.
+func dateRangeParams(r *http.Request) (models.DateRange, error) {
+	var dates models.DateRange
+	var err error
+
+	if dates.From, err = timeParam(r, "from", time.Time{}); err != nil {
+		return dates, err
+	}
+	if dates.To, err = timeParam(r, "to", time.Time{}); err != nil {
+		return dates, err
+	}
+
+	return dates, dates.Validate()
+}
+
+func decodeBody(r *http.Request, v any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return nil
+}
+
+// nonNil makes empty results encode as [] rather than null.
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	var invalid *storage.ValidationError
+	if errors.As(err, &invalid) {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: invalid.Error(), Fields: invalid.Fields})
+		return
+	}
+	if errors.Is(err, storage.ErrOrderNotFound) || errors.Is(err, storage.ErrOrderTypeNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if errors.Is(err, storage.ErrConflict) {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if errors.Is(err, storage.ErrRateNotFound) {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	var timeout *storage.TimeoutError
+	if errors.As(err, &timeout) {
+		slog.Error("api request timed out", "error", err)
+		writeError(w, http.StatusGatewayTimeout, timeout)
+		return
+	}
+
+	slog.Error("api request failed", "error", err)
+	writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("couldn't write response", "error", err)
+	}
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"coursework/internal/config"
+	"coursework/internal/memory"
+	"coursework/internal/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func newTestHandler(t *testing.T) http.Handler {
+	t.Helper()
+
+	store := memory.NewStore()
+	at := time.Date(2025, 12, 1, 9, 30, 0, 0, time.UTC)
+	if err := store.AddNewOrder(t.Context(), at, "харчування", decimal.NewFromInt(20), "UAH", decimal.NewFromInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddNewOrder(t.Context(), at.Add(time.Hour), "одяг", decimal.NewFromInt(100), "USD", decimal.RequireFromString("41.2")); err != nil {
+		t.Fatal(err)
+	}
+
+	return NewHandler(store, config.Default().Reports)
+}
+
+func do(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestOrders(t *testing.T) {
+	t.Run("listing orders", func(t *testing.T) {
+		rec := do(newTestHandler(t), http.MethodGet, "/orders?limit=1", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", rec.Code, http.StatusOK)
+		}
+
+		var orders []models.Order
+		if err := json.NewDecoder(rec.Body).Decode(&orders); err != nil {
+			t.Fatal(err)
+		}
+		if len(orders) != 1 || orders[0].Type != "харчування" {
+			t.Errorf("got %v", orders)
+		}
+	})
+
+	t.Run("paging through filtered orders", func(t *testing.T) {
+		handler := newTestHandler(t)
+
+		rec := do(handler, http.MethodGet, "/orders?limit=1&sort=amountUah&desc=true&min-amount=10&uah=true", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+		}
+
+		link := rec.Header().Get("Link")
+		start, end := strings.Index(link, "<"), strings.Index(link, ">")
+		if start < 0 || end < start || !strings.HasSuffix(link, `rel="next"`) {
+			t.Fatalf("got Link %q", link)
+		}
+
+		rec = do(handler, http.MethodGet, link[start+1:end], "")
+		var orders []models.Order
+		if err := json.NewDecoder(rec.Body).Decode(&orders); err != nil {
+			t.Fatal(err)
+		}
+		if len(orders) != 1 || orders[0].Type != "харчування" {
+			t.Errorf("got %v", orders)
+		}
+		if !strings.Contains(rec.Header().Get("Link"), `rel="prev"`) {
+			t.Errorf("got Link %q, want a previous page", rec.Header().Get("Link"))
+		}
+	})
+
+	t.Run("rejecting a malformed filter", func(t *testing.T) {
+		rec := do(newTestHandler(t), http.MethodGet, "/orders?sort=price&uah=maybe", "")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want %d", rec.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("adding an order", func(t *testing.T) {
+		handler := newTestHandler(t)
+		body := `{"timestamp":"2026-01-05T12:00:00Z","type":"транспорт","amount":45,"currency":"UAH","exchangeRate":1}`
+
+		rec := do(handler, http.MethodPost, "/orders", body)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+		}
+
+		rec = do(handler, http.MethodGet, "/orders", "")
+		var orders []models.Order
+		if err := json.NewDecoder(rec.Body).Decode(&orders); err != nil {
+			t.Fatal(err)
+		}
+		if len(orders) != 3 {
+			t.Errorf("got %d orders, want 3", len(orders))
+		}
+	})
+
+	t.Run("rejecting a malformed order", func(t *testing.T) {
+		rec := do(newTestHandler(t), http.MethodPost, "/orders", `{"type":"одяг"}`)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("got status %d, want %d", rec.Code, http.StatusBadRequest)
+		}
+
+		var resp errorResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		var fields []string
+		for _, f := range resp.Fields {
+			fields = append(fields, f.Field)
+		}
+		if got, want := strings.Join(fields, ","), "timestamp,amount,currency"; got != want {
+			t.Errorf("got invalid fields %s, want %s", got, want)
+		}
+	})
+
+	t.Run("updating and deleting orders", func(t *testing.T) {
+		handler := newTestHandler(t)
+
+		cases := []struct {
+			method, target, body string
+			want                 int
+		}{
+			{http.MethodPatch, "/orders/1", `{"type":"розваги"}`, http.StatusNoContent},
+			{http.MethodPatch, "/orders/42", `{"type":"розваги"}`, http.StatusNotFound},
+			{http.MethodPatch, "/orders/abc", `{"type":"розваги"}`, http.StatusBadRequest},
+			{http.MethodPatch, "/orders/1", `{"amount":"-3","currency":"EURO"}`, http.StatusBadRequest},
+			{http.MethodPatch, "/orders/1", `{}`, http.StatusBadRequest},
+			{http.MethodPatch, "/orders/1", `{"exchangeRate":"2"}`, http.StatusBadRequest},
+			{http.MethodPatch, "/orders/1", `{"type":"подарунки"}`, http.StatusBadRequest},
+			{http.MethodPatch, "/orders/1", `{"amount":"12.5","currency":"usd","exchangeRate":"41.5"}`, http.StatusNoContent},
+			{http.MethodGet, "/orders/1", "", http.StatusOK},
+			{http.MethodGet, "/orders/42", "", http.StatusNotFound},
+			{http.MethodDelete, "/orders/2", "", http.StatusNoContent},
+			{http.MethodDelete, "/orders/2", "", http.StatusConflict},
+			{http.MethodDelete, "/orders/42", "", http.StatusNotFound},
+		}
+
+		for _, c := range cases {
+			if rec := do(handler, c.method, c.target, c.body); rec.Code != c.want {
+				t.Errorf("%s %s: got status %d, want %d", c.method, c.target, rec.Code, c.want)
+			}
+		}
+	})
+}
+
+func TestOrderTypes(t *testing.T) {
+	rec := do(newTestHandler(t), http.MethodGet, "/order-types", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var types []models.OrderType
+	if err := json.NewDecoder(rec.Body).Decode(&types); err != nil {
+		t.Fatal(err)
+	}
+	if len(types) != 5 || types[0].Code != "електроніка" || types[0].NameEn != "Electronics" || !types[0].Active {
+		t.Errorf("got %+v", types)
+	}
+}
+
+func TestReports(t *testing.T) {
+	handler := newTestHandler(t)
+
+	t.Run("small orders", func(t *testing.T) {
+		rec := do(handler, http.MethodGet, "/reports/small-orders?type=харчування&threshold=50", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", rec.Code, http.StatusOK)
+		}
+
+		got := strings.TrimSpace(rec.Body.String())
+		want := `{"type":"харчування","threshold":"50","months":[{"month":"2025-12-01T00:00:00Z","orders":1}],"mean":1,"median":1,"stdDev":0}`
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("configured defaults", func(t *testing.T) {
+		defaults := config.Default().Reports
+		defaults.OrderType = "одяг"
+
+		rec := do(NewHandler(memory.NewStore(), defaults), http.MethodGet, "/reports/small-orders", "")
+		if got := rec.Body.String(); !strings.Contains(got, `"type":"одяг"`) {
+			t.Errorf("got %s, want the configured type", got)
+		}
+	})
+
+	t.Run("every report responds", func(t *testing.T) {
+		for _, target := range []string{
+			"/reports/biggest-dates?limit=3",
+			"/reports/rate-changes",
+			"/reports/smallest-types",
+			"/reports/periods",
+		} {
+			if rec := do(handler, http.MethodGet, target, ""); rec.Code != http.StatusOK {
+				t.Errorf("%s: got status %d, want %d", target, rec.Code, http.StatusOK)
+			}
+		}
+	})
+
+	t.Run("bad limit", func(t *testing.T) {
+		if rec := do(handler, http.MethodGet, "/reports/biggest-dates?limit=x", ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want %d", rec.Code, http.StatusBadRequest)
+		}
+	})
+}
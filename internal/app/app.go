@@ -2,27 +2,25 @@ package app
 
 import (
 	"bufio"
+	"context"
+	"coursework/internal/config"
 	"coursework/internal/frontend"
 	"coursework/internal/models"
-	"coursework/internal/postgres"
+	"coursework/internal/storage"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"strconv"
 	"time"
-)
-
-const (
-	biggestOrdersLimit         = 5
-	typesOfSmallestOrdersLimit = 6
 
-	typeOfOrdersLessThan = "харчування"
-	lessThanThreshold    = 50
+	"github.com/shopspring/decimal"
 )
 
-func StartupLogger() (func(), error) {
-	logFile, err := os.OpenFile("app.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// StartupLogger sends the default logger to the file at path.
+func StartupLogger(path string) (func(), error) {
+	logFile, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't create/open log file: %w", err)
 	}
@@ -40,135 +38,355 @@ func StartupLogger() (func(), error) {
 	return cleanUp, nil
 }
 
-func Menu(writer io.Writer, reader io.Reader, controller *postgres.DbController) error {
-	for {
+// Menu runs the interactive menu, printing results with renderer. The
+// reports start with the defaults. Cancelling ctx aborts the running action
+// and ends the menu, even while it waits for input.
+func Menu(ctx context.Context, writer io.Writer, reader io.Reader, controller storage.Store, renderer frontend.Renderer, defaults config.Reports) error {
+	in := bufio.NewReader(&cancelableReader{ctx: ctx, reader: reader})
+	params := newReportParams(defaults, controller.Location())
+
+	for ctx.Err() == nil {
 		frontend.PrintOptions(writer)
 
-		var userChoice string
-		_, err := fmt.Fscan(reader, &userChoice)
+		userChoice, err := frontend.TakeInput(writer, in, "")
 		if err != nil {
 			return err
 		}
 
 		switch userChoice {
 		case "1":
-			var limit int
-			fmt.Fprintf(writer, frontend.PrintLimit+"\n")
-			fmt.Fscan(reader, &limit)
-
-			err = printDb(writer, controller, limit)
+			err = listOrders(ctx, writer, in, renderer, controller)
 			if err != nil {
-				slog.Error("couldn't print db",
+				slog.Error("couldn't list orders",
 					"error", err,
-					"operation", "printDb",
-					"limit", limit)
-				return err
+					"operation", "listOrders")
 			}
+			handleError(writer, err)
 
 		case "2":
-			err = formNewOrder(writer, reader, controller)
+			err = formNewOrder(ctx, writer, in, controller)
 			handleError(writer, err)
 			if err == nil {
 				fmt.Fprintf(writer, "\nSuccessfully added new order!\n")
 			}
 		case "3":
-			err = updateOrderType(writer, reader, controller)
+			err = editOrder(ctx, writer, in, controller)
 			handleError(writer, err)
-			if err == nil {
-				fmt.Fprintf(writer, "\nOrder updated successfully\n")
-			}
 		case "4":
-			err = deleteOrder(writer, reader, controller)
+			err = deleteOrder(ctx, writer, in, controller)
 			handleError(writer, err)
 			if err == nil {
 				fmt.Fprintf(writer, "Order deleted successfully\n")
 			}
 		case "5":
-			err = showDatesWithBiggestOrders(writer, controller, biggestOrdersLimit)
+			err = askReportParams(writer, in, &params, biggestLimitParam)
+			if err == nil {
+				err = showDatesWithBiggestOrders(ctx, writer, renderer, controller, params.biggestLimit, params.dates)
+			}
 			handleError(writer, err)
 		case "6":
-			err = showOrdersWhenRateChanged(writer, controller)
+			err = askReportParams(writer, in, &params)
+			if err == nil {
+				err = showOrdersWhenRateChanged(ctx, writer, renderer, controller, params.dates)
+			}
 			handleError(writer, err)
 		case "7":
-			err = showAvgNumOfOrdersLessThen(writer, controller, typeOfOrdersLessThan, lessThanThreshold)
+			err = askReportParams(writer, in, &params, typeParam, thresholdParam)
+			if err == nil {
+				err = showSmallOrders(ctx, writer, renderer, controller, params.orderType, params.lessThan, params.dates)
+			}
 			handleError(writer, err)
 		case "8":
-			err = showTypesOfSmallestOrders(writer, controller, typesOfSmallestOrdersLimit)
+			err = askReportParams(writer, in, &params, smallestLimitParam)
+			if err == nil {
+				err = showTypesOfSmallestOrders(ctx, writer, renderer, controller, params.smallestLimit, params.dates)
+			}
 			handleError(writer, err)
 		case "9":
-			err = showStatsForPeriods(writer, controller)
+			err = askReportParams(writer, in, &params, bucketMinutesParam, shiftsParam, zoneParam, bigSaleParam)
+			if err == nil {
+				err = showStatsForPeriods(ctx, writer, renderer, controller, params.buckets, params.dates)
+			}
 			handleError(writer, err)
 		case "10":
+			err = askReportParams(writer, in, &params, granularityParam)
+			if err == nil {
+				err = showRevenue(ctx, writer, renderer, controller, params.granularity, params.dates)
+			}
+			handleError(writer, err)
+		case "11":
+			err = askReportParams(writer, in, &params)
+			if err == nil {
+				err = showBreakdown(ctx, writer, renderer, controller, params.dates)
+			}
+			handleError(writer, err)
+		case "12":
+			err = manageOrderTypes(ctx, writer, in, renderer, controller)
+			handleError(writer, err)
+		case "13":
 			return fmt.Errorf("exit program")
 		default:
 			fmt.Fprintf(writer, frontend.InvalidChoice+"\n\n")
 		}
 
 	}
+
+	return ctx.Err()
+}
+
+// cancelableReader returns from a read as soon as ctx is cancelled. The
+// underlying read is left running, and its input is lost.
+type cancelableReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+type readResult struct {
+	n   int
+	err error
+}
+
+func (r *cancelableReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	// the abandoned read must not write into p after Read returns
+	buf := make([]byte, len(p))
+	done := make(chan readResult, 1)
+	go func() {
+		n, err := r.reader.Read(buf)
+		done <- readResult{n, err}
+	}()
+
+	select {
+	case res := <-done:
+		return copy(p, buf[:res.n]), res.err
+	case <-r.ctx.Done():
+		return 0, r.ctx.Err()
+	}
 }
 
 func handleError(writer io.Writer, err error) {
-	if err != nil {
-		slog.Error("", "error", err)
+	if err == nil {
+		return
+	}
+	slog.Error("", "error", err)
+
+	var timeout *storage.TimeoutError
+	var invalid *storage.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		fmt.Fprintln(writer, "Please correct the following:")
+		for _, field := range invalid.Fields {
+			fmt.Fprintf(writer, "  %s\n", field)
+		}
+	case errors.Is(err, storage.ErrOrderNotFound):
+		fmt.Fprintln(writer, "There is no order with this id.")
+	case errors.Is(err, storage.ErrConflict):
+		fmt.Fprintln(writer, "The order can't be changed in its current state, e.g. it is already deleted.")
+	case errors.Is(err, storage.ErrOrderTypeNotFound):
+		fmt.Fprintln(writer, "There is no order type with this code.")
+	case errors.Is(err, storage.ErrRateNotFound):
+		fmt.Fprintln(writer, "There is no official exchange rate for this currency and date, enter the rate.")
+	case errors.As(err, &timeout):
+		fmt.Fprintf(writer, "Stopped %s after %s, try a shorter date range.\n", timeout.Op, timeout.Timeout)
+	case errors.Is(err, context.Canceled):
+		fmt.Fprintln(writer, "Cancelled.")
+	default:
 		fmt.Fprintln(writer, "Something went wrong, try again.")
 	}
 }
 
-func printDb(writer io.Writer, controller *postgres.DbController, limit int) error {
-	orders, err := controller.SelectAllOrders(limit)
+func invalidId(input string) error {
+	return storage.Invalid("order id", "must be a whole number, got %q", input)
+}
+
+func invalidNumber(field, input string) error {
+	return storage.Invalid(field, "must be a number, got %q", input)
+}
+
+func invalidTime(field, input string) error {
+	return storage.Invalid(field, "must look like %s, got %q", frontend.TimeFormat, input)
+}
+
+// listOrders asks for a filter, a sort order and a page size, then pages
+// through the matching orders until the user quits.
+func listOrders(ctx context.Context, writer io.Writer, reader *bufio.Reader, renderer frontend.Renderer, controller storage.Store) error {
+	query, err := askOrderQuery(writer, reader, controller.Location())
 	if err != nil {
-		return fmt.Errorf("couldn`t print db: %w", err)
+		return fmt.Errorf("couldn't list orders: %w", err)
 	}
 
-	frontend.PrintTable(writer, orders)
-	return nil
+	for {
+		page, err := controller.ListOrders(ctx, query)
+		if err != nil {
+			return fmt.Errorf("couldn't list orders: %w", err)
+		}
+
+		fmt.Fprintln(writer)
+		if err = frontend.PrintTable(writer, renderer, page.Orders); err != nil {
+			return err
+		}
+
+		if page.Next == "" && page.Prev == "" {
+			return nil
+		}
+
+		choice, err := frontend.TakeInput(writer, reader, frontend.PageNavigation)
+		if err != nil {
+			return fmt.Errorf("couldn't list orders: %w", err)
+		}
+
+		var token string
+		switch choice {
+		case "n":
+			token = page.Next
+		case "p":
+			token = page.Prev
+		case "q", "":
+			return nil
+		default:
+			fmt.Fprintln(writer, frontend.InvalidChoice)
+			continue
+		}
+
+		if token == "" {
+			fmt.Fprintln(writer, frontend.NoMorePages)
+			continue
+		}
+
+		cursor, err := models.DecodeCursor(token)
+		if err != nil {
+			return fmt.Errorf("couldn't list orders: %w", err)
+		}
+		if choice == "n" {
+			query.After, query.Before = &cursor, nil
+		} else {
+			query.After, query.Before = nil, &cursor
+		}
+	}
 }
 
-func formNewOrder(writer io.Writer, reader io.Reader, controller *postgres.DbController) error {
+func askOrderQuery(writer io.Writer, reader *bufio.Reader, location *time.Location) (models.OrderQuery, error) {
+	var query models.OrderQuery
+
+	input, err := frontend.TakeInput(writer, reader, frontend.PrintLimit+"\n")
+	if err != nil {
+		return query, err
+	}
+	if input != "" {
+		if query.Limit, err = strconv.Atoi(input); err != nil {
+			return query, invalidNumber("page size", input)
+		}
+	}
+
+	fmt.Fprintf(writer, "Press Enter to skip a filter. Times are written as %s.\n", frontend.TimeFormat)
+
+	ask := func(prompt string, parse func(string) error) error {
+		value, err := frontend.TakeInput(writer, reader, prompt)
+		if err != nil || value == "" {
+			return err
+		}
+		return parse(value)
+	}
+	parseTime := func(field string, dest *time.Time) func(string) error {
+		return func(value string) (err error) {
+			if *dest, err = time.ParseInLocation(frontend.TimeFormat, value, location); err != nil {
+				return invalidTime(field, value)
+			}
+			return nil
+		}
+	}
+	parseDecimal := func(field string, dest **decimal.Decimal) func(string) error {
+		return func(value string) error {
+			d, err := decimal.NewFromString(value)
+			if err != nil {
+				return invalidNumber(field, value)
+			}
+			*dest = &d
+			return nil
+		}
+	}
+	parseYes := func(dest *bool) func(string) error {
+		return func(value string) error {
+			*dest = value == "y" || value == "yes"
+			return nil
+		}
+	}
+
+	f := &query.Filter
+	steps := []struct {
+		prompt string
+		parse  func(string) error
+	}{
+		{"From: ", parseTime("from", &f.From)},
+		{"To: ", parseTime("to", &f.To)},
+		{"Order type: ", func(value string) error { f.Type = value; return nil }},
+		{"Currency: ", func(value string) error { f.Currency = value; return nil }},
+		{"Min amount: ", parseDecimal("min amount", &f.MinAmount)},
+		{"Max amount: ", parseDecimal("max amount", &f.MaxAmount)},
+		{"Amounts in UAH? (y/N): ", parseYes(&f.InUah)},
+		{fmt.Sprintf("Sort by %v: ", models.SortFields), func(value string) (err error) {
+			query.Sort, err = models.ParseSortField(value)
+			return err
+		}},
+		{"Descending? (y/N): ", parseYes(&query.Desc)},
+	}
+	for _, step := range steps {
+		if err = ask(step.prompt, step.parse); err != nil {
+			return query, err
+		}
+	}
+
+	return query, query.Validate()
+}
+
+func formNewOrder(ctx context.Context, writer io.Writer, reader *bufio.Reader, controller storage.Store) error {
 	var order models.Order
-	var OrderTimeStampInput string
 
-	scanner := bufio.NewScanner(reader)
 	fmt.Fprintf(writer, "Write the date and time of order in following format: (%s)\n", frontend.TimeFormat)
-	fmt.Fprintf(writer, "Order date: ")
-	if scanner.Scan() {
-		OrderTimeStampInput = scanner.Text()
-	}
-	var err = scanner.Err()
+	input, err := frontend.TakeInput(writer, reader, "Order date: ")
 	if err != nil {
 		return fmt.Errorf("couldn't form new order: %w", err)
 	}
 
-	order.TimeStamp, err = time.Parse(frontend.TimeFormat, OrderTimeStampInput)
+	order.TimeStamp, err = time.ParseInLocation(frontend.TimeFormat, input, controller.Location())
 	if err != nil {
-		return fmt.Errorf("couldn't form new order: %w", err)
+		return fmt.Errorf("couldn't form new order: %w", invalidTime("order date", input))
 	}
 
-	fmt.Fprintf(writer, "Order type: ")
-	_, err = fmt.Fscan(reader, &order.Type)
+	order.Type, err = frontend.TakeInput(writer, reader, "Order type: ")
 	if err != nil {
 		return fmt.Errorf("couldn't form new order: %w", err)
 	}
 
-	fmt.Fprintf(writer, "Pay amount: ")
-	_, err = fmt.Fscan(reader, &order.Amount)
+	input, err = frontend.TakeInput(writer, reader, "Pay amount: ")
 	if err != nil {
 		return fmt.Errorf("couldn't form new order: %w", err)
 	}
+	order.Amount, err = decimal.NewFromString(input)
+	if err != nil {
+		return fmt.Errorf("couldn't form new order: %w", invalidNumber("amount", input))
+	}
 
-	fmt.Fprintf(writer, "Currency: ")
-	_, err = fmt.Fscan(reader, &order.Currency)
+	order.Currency, err = frontend.TakeInput(writer, reader, "Currency: ")
 	if err != nil {
 		return fmt.Errorf("couldn't form new order: %w", err)
 	}
 
-	fmt.Fprintf(writer, "Exchange rate: ")
-	_, err = fmt.Fscan(reader, &order.ExchangeRate)
+	input, err = frontend.TakeInput(writer, reader, "Exchange rate (empty for the official rate): ")
 	if err != nil {
 		return fmt.Errorf("couldn't form new order: %w", err)
 	}
+	if input != "" {
+		order.ExchangeRate, err = decimal.NewFromString(input)
+		if err != nil {
+			return fmt.Errorf("couldn't form new order: %w", invalidNumber("exchange rate", input))
+		}
+	}
 
-	err = controller.AddNewOrder(order.TimeStamp, order.Type, order.Amount, order.Currency, order.ExchangeRate)
+	err = controller.AddNewOrder(ctx, order.TimeStamp, order.Type, order.Amount, order.Currency, order.ExchangeRate)
 
 	if err != nil {
 		return fmt.Errorf("failed to add new order: %w", err)
@@ -177,33 +395,101 @@ func formNewOrder(writer io.Writer, reader io.Reader, controller *postgres.DbCon
 	return nil
 }
 
-func updateOrderType(writer io.Writer, reader io.Reader, controller *postgres.DbController) error {
-	var orderTypeNew string
-	var orderId int
-
+func editOrder(ctx context.Context, writer io.Writer, reader *bufio.Reader, controller storage.Store) error {
 	input, err := frontend.TakeInput(writer, reader, "Enter order id: ")
 	if err != nil {
-		return fmt.Errorf("error updating order's type: %w", err)
+		return fmt.Errorf("error editing order: %w", err)
+	}
+	orderId, err := strconv.Atoi(input)
+	if err != nil {
+		return fmt.Errorf("error editing order: %w", invalidId(input))
+	}
+
+	order, err := controller.GetOrder(ctx, orderId)
+	if err != nil {
+		return fmt.Errorf("error editing order: %w", err)
+	}
+
+	fmt.Fprintf(writer, "Press Enter to keep the current value.\n")
+
+	var patch models.OrderPatch
+	ask := func(label, current string) (string, bool, error) {
+		value, err := frontend.TakeInput(writer, reader, fmt.Sprintf("%s [%s]: ", label, current))
+		if err != nil || value == "" || value == current {
+			return "", false, err
+		}
+		return value, true, nil
+	}
+
+	value, changed, err := ask("Order date", order.TimeStamp.Format(frontend.TimeFormat))
+	if err != nil {
+		return fmt.Errorf("error editing order: %w", err)
+	}
+	if changed {
+		timeStamp, err := time.ParseInLocation(frontend.TimeFormat, value, controller.Location())
+		if err != nil {
+			return fmt.Errorf("error editing order: %w", invalidTime("order date", value))
+		}
+		patch.TimeStamp = &timeStamp
+	}
+
+	value, changed, err = ask("Order type", order.Type)
+	if err != nil {
+		return fmt.Errorf("error editing order: %w", err)
 	}
-	orderId, err = strconv.Atoi(input)
+	if changed {
+		orderType := value
+		patch.Type = &orderType
+	}
+
+	value, changed, err = ask("Pay amount", order.Amount.StringFixed(2))
 	if err != nil {
-		return fmt.Errorf("error updating order's type: %w", err)
+		return fmt.Errorf("error editing order: %w", err)
+	}
+	if changed {
+		amount, err := decimal.NewFromString(value)
+		if err != nil {
+			return fmt.Errorf("error editing order: %w", invalidNumber("amount", value))
+		}
+		patch.Amount = &amount
 	}
 
-	input, err = frontend.TakeInput(writer, reader, "Enter new order type: ")
+	value, changed, err = ask("Currency", order.Currency)
 	if err != nil {
-		return fmt.Errorf("error updating order's type: %w", err)
+		return fmt.Errorf("error editing order: %w", err)
+	}
+	if changed {
+		currency := value
+		patch.Currency = &currency
+	}
+
+	value, changed, err = ask("Exchange rate", order.ExchangeRate.StringFixed(6))
+	if err != nil {
+		return fmt.Errorf("error editing order: %w", err)
+	}
+	if changed {
+		rate, err := decimal.NewFromString(value)
+		if err != nil {
+			return fmt.Errorf("error editing order: %w", invalidNumber("exchange rate", value))
+		}
+		patch.ExchangeRate = &rate
+	}
+
+	if patch.IsEmpty() {
+		fmt.Fprintf(writer, "\nNothing to change\n")
+		return nil
 	}
 
-	err = controller.UpdateOrder(orderId, orderTypeNew)
+	err = controller.PatchOrder(ctx, orderId, patch)
 	if err != nil {
-		return fmt.Errorf("error updating order's type: %w", err)
+		return fmt.Errorf("error editing order: %w", err)
 	}
 
+	fmt.Fprintf(writer, "\nOrder updated successfully\n")
 	return nil
 }
 
-func deleteOrder(writer io.Writer, reader io.Reader, controller *postgres.DbController) error {
+func deleteOrder(ctx context.Context, writer io.Writer, reader *bufio.Reader, controller storage.Store) error {
 	var orderId int
 
 	inputId, err := frontend.TakeInput(writer, reader, "Enter order id: ")
@@ -213,10 +499,10 @@ func deleteOrder(writer io.Writer, reader io.Reader, controller *postgres.DbCont
 
 	orderId, err = strconv.Atoi(inputId)
 	if err != nil {
-		return fmt.Errorf("error deleting order: %w", err)
+		return fmt.Errorf("error deleting order: %w", invalidId(inputId))
 	}
 
-	err = controller.DeleteOrder(orderId)
+	err = controller.DeleteOrder(ctx, orderId)
 	if err != nil {
 		return fmt.Errorf("error deleting order: %w", err)
 	}
@@ -224,62 +510,73 @@ func deleteOrder(writer io.Writer, reader io.Reader, controller *postgres.DbCont
 	return nil
 }
 
-func showDatesWithBiggestOrders(writer io.Writer, controller *postgres.DbController, limit int) error {
-	orders, err := controller.DatesWithBiggestOrders(limit)
+func showDatesWithBiggestOrders(ctx context.Context, writer io.Writer, renderer frontend.Renderer, controller storage.Store, limit int, dates models.DateRange) error {
+	orders, err := controller.DatesWithBiggestOrders(ctx, limit, dates)
 	if err != nil {
 		return fmt.Errorf("couldn't show biggest orders: %w", err)
 	}
 
-	frontend.PrintBiggestOrders(writer, orders)
-
-	return nil
+	fmt.Fprintln(writer)
+	return frontend.PrintBiggestOrders(writer, renderer, orders)
 }
 
-func showTypesOfSmallestOrders(writer io.Writer, controller *postgres.DbController, limit int) error {
-	orderTypes, err := controller.TypeOfSmallestOrders(limit)
+func showTypesOfSmallestOrders(ctx context.Context, writer io.Writer, renderer frontend.Renderer, controller storage.Store, limit int, dates models.DateRange) error {
+	orderTypes, err := controller.TypeOfSmallestOrders(ctx, limit, dates)
 	if err != nil {
 		return fmt.Errorf("couldn't show types of smallest orders: %w", err)
 	}
 
-	fmt.Println("")
-	for _, orderType := range orderTypes {
-		fmt.Fprintf(writer, "%s\n", orderType)
-	}
-
-	return nil
+	fmt.Fprintln(writer)
+	return frontend.PrintTypes(writer, renderer, orderTypes)
 }
 
-func showOrdersWhenRateChanged(writer io.Writer, controller *postgres.DbController) error {
-	rows, err := controller.OrdersWhenRateChanged()
+func showOrdersWhenRateChanged(ctx context.Context, writer io.Writer, renderer frontend.Renderer, controller storage.Store, dates models.DateRange) error {
+	rows, err := controller.OrdersWhenRateChanged(ctx, dates)
 	if err != nil {
 		return fmt.Errorf("couldn't show orders when rate changed: %w", err)
 	}
 
-	frontend.PrintTable(writer, rows)
+	fmt.Fprintln(writer)
+	return frontend.PrintTable(writer, renderer, rows)
+}
 
-	return nil
+func showSmallOrders(ctx context.Context, writer io.Writer, renderer frontend.Renderer, controller storage.Store, orderType string, lessThen decimal.Decimal, dates models.DateRange) error {
+	distribution, err := controller.MonthlyDistribution(ctx, orderType, lessThen, dates)
+	if err != nil {
+		return fmt.Errorf("couldn't show small orders: %w", err)
+	}
+
+	fmt.Fprintln(writer)
+	return frontend.PrintSmallOrders(writer, renderer, distribution)
 }
 
-func showAvgNumOfOrdersLessThen(writer io.Writer, controller *postgres.DbController, orderType string, lessThen float64) error {
+func showStatsForPeriods(ctx context.Context, writer io.Writer, renderer frontend.Renderer, controller storage.Store, buckets models.Buckets, dates models.DateRange) error {
 
-	avgNum, err := controller.GetAvgNumOfOrdersLessThan(orderType, lessThen)
+	stats, err := controller.GetTableForPeriods(ctx, buckets, dates)
 	if err != nil {
-		return fmt.Errorf("couldn't show avg-num of orders: %w", err)
+		return fmt.Errorf("couldn't show stats: %w", err)
 	}
 
-	fmt.Fprintf(writer, "\nAvg num of orders of type %s per month less then %.2f: %.2f\n", orderType, lessThen, avgNum)
-
-	return nil
+	fmt.Fprintln(writer)
+	return frontend.PrintStats(writer, renderer, stats)
 }
 
-func showStatsForPeriods(writer io.Writer, controller *postgres.DbController) error {
-
-	stats, err := controller.GetTableForPeriods()
+func showRevenue(ctx context.Context, writer io.Writer, renderer frontend.Renderer, controller storage.Store, granularity models.Granularity, dates models.DateRange) error {
+	points, err := controller.RevenueSeries(ctx, granularity, dates)
 	if err != nil {
-		fmt.Errorf("couldn't show stats: %w", err)
+		return fmt.Errorf("couldn't show revenue: %w", err)
 	}
 
-	frontend.PrintStats(writer, stats)
+	fmt.Fprintln(writer)
+	return frontend.PrintRevenue(writer, renderer, points)
+}
 
-	return nil
+func showBreakdown(ctx context.Context, writer io.Writer, renderer frontend.Renderer, controller storage.Store, dates models.DateRange) error {
+	b, err := controller.Breakdown(ctx, dates)
+	if err != nil {
+		return fmt.Errorf("couldn't show breakdown: %w", err)
+	}
+
+	fmt.Fprintln(writer)
+	return frontend.PrintBreakdown(writer, renderer, b)
 }
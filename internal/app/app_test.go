@@ -0,0 +1,336 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"coursework/internal/config"
+	"coursework/internal/frontend"
+	"coursework/internal/memory"
+	"coursework/internal/storage"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func newTestStore(t *testing.T) *memory.Store {
+	t.Helper()
+
+	store := memory.NewStore()
+	orders := []struct {
+		at       string
+		kind     string
+		amount   string
+		currency string
+		rate     string
+	}{
+		{"2025-12-08 21:04:00", "розваги", "1639.97", "UAH", "1"},
+		{"2025-12-19 18:09:00", "розваги", "4390.89", "USD", "41.2"},
+		{"2025-12-20 12:04:00", "харчування", "3595.27", "USD", "40.757892"},
+	}
+
+	for _, o := range orders {
+		at, err := time.Parse("2006-01-02 15:04:05", o.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = store.AddNewOrder(t.Context(), at, o.kind, decimal.RequireFromString(o.amount), o.currency, decimal.RequireFromString(o.rate))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return store
+}
+
+func TestListOrders(t *testing.T) {
+	ctx := t.Context()
+	t.Run("printing first 2 elements of db", func(t *testing.T) {
+		controller := newTestStore(t)
+
+		// page size 2, no filters, then quit
+		input := "2\n\n\n\n\n\n\n\n\n\nq\n"
+		buffer := &bytes.Buffer{}
+		err := listOrders(ctx, buffer, bufio.NewReader(strings.NewReader(input)), frontend.TextRenderer{}, controller)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := "\n" +
+			"id  timestamp            type     amount   currency  exchangeRate  amountUah\n" +
+			"1   2025-12-08 21:04:00  розваги  1639.97  UAH       1.000000      1639.97\n" +
+			"2   2025-12-19 18:09:00  розваги  4390.89  USD       41.200000     180904.67\n"
+
+		if got := buffer.String(); !strings.Contains(got, want) {
+			t.Errorf("got %q, want it to contain %q", got, want)
+		}
+	})
+
+	t.Run("filtering, sorting and paging", func(t *testing.T) {
+		controller := newTestStore(t)
+
+		// one order per page of orders of розваги or харчування over 2000 UAH,
+		// biggest first: next, next (there is none), previous, quit
+		input := "1\n\n\n\n\n2000\n\ny\namountUah\ny\nn\nn\np\nq\n"
+		buffer := &bytes.Buffer{}
+		err := listOrders(ctx, buffer, bufio.NewReader(strings.NewReader(input)), frontend.TextRenderer{}, controller)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var ids []string
+		for _, line := range strings.Split(buffer.String(), "\n") {
+			if fields := strings.Fields(line); len(fields) > 0 && (fields[0] == "2" || fields[0] == "3") {
+				ids = append(ids, fields[0])
+			}
+		}
+		if got, want := strings.Join(ids, ","), "2,3,3,2"; got != want {
+			t.Errorf("got pages %s, want %s", got, want)
+		}
+		if !strings.Contains(buffer.String(), frontend.NoMorePages) {
+			t.Errorf("output doesn't report the last page: %q", buffer.String())
+		}
+	})
+}
+
+func TestInsertionToDb(t *testing.T) {
+	ctx := t.Context()
+	t.Run("insertion to db", func(t *testing.T) {
+		controller := newTestStore(t)
+
+		input := "2026-01-23 23:37:00\nодяг\n52.29\nEUR\n44.123456789\n"
+		err := formNewOrder(ctx, &bytes.Buffer{}, bufio.NewReader(strings.NewReader(input)), controller)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		orders, err := controller.SelectAllOrders(ctx, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := orders[len(orders)-1]
+		if got.Id != 4 || got.Type != "одяг" || got.Amount.String() != "52.29" || got.Currency != "EUR" ||
+			got.ExchangeRate.String() != "44.123457" {
+			t.Errorf("got %+v", got)
+		}
+	})
+}
+
+func TestMenu(t *testing.T) {
+	ctx := t.Context()
+	t.Run("deleting an order and exiting", func(t *testing.T) {
+		controller := newTestStore(t)
+
+		buffer := &bytes.Buffer{}
+		err := Menu(ctx, buffer, strings.NewReader("4\n2\n13\n"), controller, frontend.TextRenderer{}, config.Default().Reports)
+		if err == nil || err.Error() != "exit program" {
+			t.Fatalf("got error %v, want exit program", err)
+		}
+
+		if !strings.Contains(buffer.String(), "Order deleted successfully") {
+			t.Errorf("menu output doesn't report deletion: %q", buffer.String())
+		}
+
+		orders, _ := controller.SelectAllOrders(ctx, 0)
+		if len(orders) != 2 {
+			t.Errorf("got %d orders, want 2", len(orders))
+		}
+	})
+
+	t.Run("managing order types", func(t *testing.T) {
+		controller := newTestStore(t)
+
+		// add подарунки, add it again, rename одяг, deactivate an unknown
+		// type, then deactivate розваги
+		input := "12\na\nподарунки\nПодарунки\nGifts\na\nподарунки\nПодарунки\nGifts\n" +
+			"r\nодяг\n\nApparel\nd\nкниги\nd\nрозваги\nq\n13\n"
+		buffer := &bytes.Buffer{}
+		err := Menu(ctx, buffer, strings.NewReader(input), controller, frontend.TextRenderer{}, config.Default().Reports)
+		if err == nil || err.Error() != "exit program" {
+			t.Fatalf("got error %v, want exit program", err)
+		}
+
+		for _, want := range []string{
+			"Order type подарунки added successfully",
+			"There already is an order type with this code.",
+			"Order type одяг renamed successfully",
+			"There is no order type with this code.",
+			"Order type розваги deactivated successfully",
+		} {
+			if !strings.Contains(buffer.String(), want) {
+				t.Errorf("output doesn't contain %q: %q", want, buffer.String())
+			}
+		}
+
+		types, _ := controller.OrderTypes(ctx, false)
+		var codes []string
+		for _, orderType := range types {
+			codes = append(codes, orderType.Code+"="+orderType.NameEn)
+		}
+		if got, want := strings.Join(codes, ","), "електроніка=Electronics,одяг=Apparel,подарунки=Gifts,транспорт=Transport,харчування=Food"; got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	})
+
+	t.Run("cancelling stops the menu waiting for input", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		controller := newTestStore(t)
+		reader, writer := io.Pipe()
+		defer writer.Close()
+
+		errs := make(chan error, 1)
+		go func() {
+			errs <- Menu(ctx, &bytes.Buffer{}, reader, controller, frontend.TextRenderer{}, config.Default().Reports)
+		}()
+		cancel()
+
+		select {
+		case err := <-errs:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("got %v, want %v", err, context.Canceled)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("menu still waits for input")
+		}
+	})
+
+	t.Run("cancelled context stops the menu", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err := Menu(ctx, &bytes.Buffer{}, strings.NewReader("1\n"), newTestStore(t), frontend.TextRenderer{}, config.Default().Reports)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want %v", err, context.Canceled)
+		}
+	})
+}
+
+func TestHandleError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"timeout", fmt.Errorf("couldn't get report: %w", &storage.TimeoutError{Op: "getting breakdown", Timeout: time.Minute, Err: context.DeadlineExceeded}),
+			"Stopped getting breakdown after 1m0s, try a shorter date range.\n"},
+		{"cancelled", fmt.Errorf("couldn't get report: %w", context.Canceled), "Cancelled.\n"},
+		{"invalid fields", fmt.Errorf("couldn't form new order: %w", &storage.ValidationError{Fields: []storage.FieldError{
+			{Field: "amount", Message: "must be positive, got -1"},
+			{Field: "currency", Message: `must be a 3-letter code, got "EURO"`},
+		}}), "Please correct the following:\n  amount: must be positive, got -1\n  currency: must be a 3-letter code, got \"EURO\"\n"},
+		{"not found", fmt.Errorf("error deleting order: %w", storage.ErrOrderNotFound), "There is no order with this id.\n"},
+		{"type not found", fmt.Errorf("couldn't rename order type: %w", storage.ErrOrderTypeNotFound), "There is no order type with this code.\n"},
+		{"conflict", fmt.Errorf("error deleting order: %w", storage.ErrConflict),
+			"The order can't be changed in its current state, e.g. it is already deleted.\n"},
+		{"other", errors.New("boom"), "Something went wrong, try again.\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			handleError(buffer, c.err)
+			if buffer.String() != c.want {
+				t.Errorf("got %q, want %q", buffer.String(), c.want)
+			}
+		})
+	}
+}
+
+func TestEditOrder(t *testing.T) {
+	ctx := t.Context()
+	t.Run("keeping and replacing values", func(t *testing.T) {
+		controller := newTestStore(t)
+
+		input := "2\n\nодяг\n100\n\n41.5\n"
+		buffer := &bytes.Buffer{}
+		err := editOrder(ctx, buffer, bufio.NewReader(strings.NewReader(input)), controller)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(buffer.String(), "Order type [розваги]: ") {
+			t.Errorf("current value isn't shown: %q", buffer.String())
+		}
+
+		got, _ := controller.GetOrder(ctx, 2)
+		if got.TimeStamp.Format("2006-01-02 15:04:05") != "2025-12-19 18:09:00" || got.Type != "одяг" ||
+			got.Amount.String() != "100" || got.Currency != "USD" || got.ExchangeRate.String() != "41.5" {
+			t.Errorf("got %+v", got)
+		}
+	})
+
+	t.Run("invalid amount", func(t *testing.T) {
+		controller := newTestStore(t)
+
+		input := "2\n\n\n-5\n\n\n"
+		err := editOrder(ctx, &bytes.Buffer{}, bufio.NewReader(strings.NewReader(input)), controller)
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestReportParams(t *testing.T) {
+	ctx := t.Context()
+	t.Run("remembering entered values", func(t *testing.T) {
+		controller := newTestStore(t)
+
+		// biggest dates of December 20 on, then again keeping every value
+		input := "5\n1\n2025-12-20 00:00:00\n\n5\n\n\n\n13\n"
+		buffer := &bytes.Buffer{}
+		err := Menu(ctx, buffer, strings.NewReader(input), controller, frontend.TextRenderer{}, config.Default().Reports)
+		if err == nil || err.Error() != "exit program" {
+			t.Fatalf("got error %v, want exit program", err)
+		}
+
+		got := buffer.String()
+		if !strings.Contains(got, "Limit [5]: ") || !strings.Contains(got, "Limit [1]: ") ||
+			!strings.Contains(got, "From [2025-12-20 00:00:00]: ") {
+			t.Errorf("current values aren't offered: %q", got)
+		}
+		if n := strings.Count(got, "\n2025-12-20  "); n != 2 {
+			t.Errorf("got the biggest date %d times, want 2: %q", n, got)
+		}
+		if strings.Contains(got, "\n2025-12-19  ") {
+			t.Errorf("got a date before the range: %q", got)
+		}
+	})
+
+	t.Run("malformed numbers are invalid fields", func(t *testing.T) {
+		params := newReportParams(config.Default().Reports, time.UTC)
+
+		for _, c := range []struct {
+			param reportParam
+			want  string
+		}{
+			{biggestLimitParam, "Please correct the following:\n  limit: must be a number, got \"x\"\n"},
+			{bucketMinutesParam, "Please correct the following:\n  bucket width: must be a number, got \"x\"\n"},
+		} {
+			err := askReportParams(&bytes.Buffer{}, bufio.NewReader(strings.NewReader("x\n")), &params, c.param)
+			buffer := &bytes.Buffer{}
+			handleError(buffer, err)
+			if buffer.String() != c.want {
+				t.Errorf("got %q, want %q", buffer.String(), c.want)
+			}
+		}
+	})
+
+	t.Run("invalid range keeps the old values", func(t *testing.T) {
+		params := newReportParams(config.Default().Reports, time.UTC)
+
+		input := "\n2025-12-20 00:00:00\n2025-12-19 00:00:00\n"
+		err := askReportParams(&bytes.Buffer{}, bufio.NewReader(strings.NewReader(input)), &params, biggestLimitParam)
+		if err == nil {
+			t.Error("expected an error")
+		}
+		if !params.dates.From.IsZero() {
+			t.Errorf("got from %v, want it unset", params.dates.From)
+		}
+	})
+}
@@ -0,0 +1,218 @@
+package app
+
+import (
+	"bufio"
+	"coursework/internal/config"
+	"coursework/internal/frontend"
+	"coursework/internal/models"
+	"coursework/internal/storage"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// reportParams are the parameters of the reports. The menu remembers the
+// last values entered and offers them as defaults.
+type reportParams struct {
+	biggestLimit  int
+	smallestLimit int
+	orderType     string
+	lessThan      decimal.Decimal
+	buckets       models.Buckets
+	granularity   models.Granularity
+	dates         models.DateRange
+	// location is the business time zone the dates are entered in.
+	location *time.Location
+}
+
+func newReportParams(defaults config.Reports, location *time.Location) reportParams {
+	buckets := defaults.Buckets()
+	buckets.Location = location
+
+	return reportParams{
+		biggestLimit:  defaults.BiggestLimit,
+		smallestLimit: defaults.SmallestLimit,
+		orderType:     defaults.OrderType,
+		lessThan:      defaults.LessThan,
+		buckets:       buckets,
+		granularity:   defaults.Granularity,
+		location:      location,
+	}
+}
+
+// reportParam is one prompt of askReportParams: current shows the value
+// offered as the default and set stores a new one.
+type reportParam struct {
+	label   string
+	current func(p *reportParams) string
+	set     func(p *reportParams, value string) error
+}
+
+// noValue is entered to clear a bound of the date range or the shifts.
+const noValue = "-"
+
+var (
+	biggestLimitParam  = limitParam(func(p *reportParams) *int { return &p.biggestLimit })
+	smallestLimitParam = limitParam(func(p *reportParams) *int { return &p.smallestLimit })
+
+	typeParam = reportParam{
+		label:   "Order type",
+		current: func(p *reportParams) string { return p.orderType },
+		set: func(p *reportParams, value string) error {
+			p.orderType = value
+			return nil
+		},
+	}
+	thresholdParam = decimalParam("Less than, UAH", func(p *reportParams) *decimal.Decimal { return &p.lessThan })
+
+	bucketMinutesParam = reportParam{
+		label:   "Bucket width, minutes",
+		current: func(p *reportParams) string { return strconv.Itoa(p.buckets.Minutes) },
+		set: func(p *reportParams, value string) error {
+			minutes, err := strconv.Atoi(value)
+			if err != nil {
+				return invalidNumber("bucket width", value)
+			}
+			p.buckets.Minutes = minutes
+			return nil
+		},
+	}
+	shiftsParam = reportParam{
+		label: "Shifts instead, as name=HH:MM-HH:MM,...",
+		current: func(p *reportParams) string {
+			if len(p.buckets.Shifts) == 0 {
+				return noValue
+			}
+			names := make([]string, len(p.buckets.Shifts))
+			for i, shift := range p.buckets.Shifts {
+				names[i] = shift.String()
+			}
+			return strings.Join(names, ",")
+		},
+		set: func(p *reportParams, value string) error {
+			p.buckets.Shifts = nil
+			if value == noValue {
+				return nil
+			}
+			for _, raw := range strings.Split(value, ",") {
+				shift, err := models.ParseShift(raw)
+				if err != nil {
+					return err
+				}
+				p.buckets.Shifts = append(p.buckets.Shifts, shift)
+			}
+			return nil
+		},
+	}
+	zoneParam = reportParam{
+		label:   "Time zone",
+		current: func(p *reportParams) string { return p.buckets.Location.String() },
+		set: func(p *reportParams, value string) (err error) {
+			p.buckets.Location, err = time.LoadLocation(value)
+			return err
+		},
+	}
+	bigSaleParam = decimalParam("Big sale above, UAH", func(p *reportParams) *decimal.Decimal { return &p.buckets.BigSale })
+
+	granularityParam = reportParam{
+		label:   fmt.Sprintf("Period %v", models.Granularities),
+		current: func(p *reportParams) string { return string(p.granularity) },
+		set: func(p *reportParams, value string) (err error) {
+			p.granularity, err = models.ParseGranularity(value)
+			return err
+		},
+	}
+
+	fromParam = dateParam("From", func(p *reportParams) *time.Time { return &p.dates.From })
+	toParam   = dateParam("To", func(p *reportParams) *time.Time { return &p.dates.To })
+)
+
+func limitParam(field func(p *reportParams) *int) reportParam {
+	return reportParam{
+		label:   "Limit",
+		current: func(p *reportParams) string { return strconv.Itoa(*field(p)) },
+		set: func(p *reportParams, value string) error {
+			limit, err := strconv.Atoi(value)
+			if err != nil {
+				return invalidNumber("limit", value)
+			}
+			if limit < 0 {
+				return storage.Invalid("limit", "must not be negative, got %d", limit)
+			}
+			*field(p) = limit
+			return nil
+		},
+	}
+}
+
+func decimalParam(label string, field func(p *reportParams) *decimal.Decimal) reportParam {
+	return reportParam{
+		label:   label,
+		current: func(p *reportParams) string { return field(p).String() },
+		set: func(p *reportParams, value string) error {
+			d, err := decimal.NewFromString(value)
+			if err != nil {
+				return invalidNumber(label, value)
+			}
+			*field(p) = d
+			return nil
+		},
+	}
+}
+
+func dateParam(label string, field func(p *reportParams) *time.Time) reportParam {
+	return reportParam{
+		label: label,
+		current: func(p *reportParams) string {
+			if field(p).IsZero() {
+				return noValue
+			}
+			return field(p).Format(frontend.TimeFormat)
+		},
+		set: func(p *reportParams, value string) error {
+			if value == noValue {
+				*field(p) = time.Time{}
+				return nil
+			}
+			t, err := time.ParseInLocation(frontend.TimeFormat, value, p.location)
+			if err != nil {
+				return invalidTime(label, value)
+			}
+			*field(p) = t
+			return nil
+		},
+	}
+}
+
+// askReportParams prompts for the given parameters followed by the date
+// range, keeping the current value of each one left empty. params is only
+// updated when all the values are valid.
+func askReportParams(writer io.Writer, reader *bufio.Reader, params *reportParams, prompts ...reportParam) error {
+	fmt.Fprintf(writer, "Press Enter to keep a value, %q clears one. Times are written as %s.\n", noValue, frontend.TimeFormat)
+
+	next := *params
+	for _, prompt := range append(prompts, fromParam, toParam) {
+		value, err := frontend.TakeInput(writer, reader, fmt.Sprintf("%s [%s]: ", prompt.label, prompt.current(&next)))
+		if err != nil {
+			return fmt.Errorf("couldn't read report parameters: %w", err)
+		}
+		if value == "" {
+			continue
+		}
+		if err = prompt.set(&next, value); err != nil {
+			return fmt.Errorf("couldn't read report parameters: %w", err)
+		}
+	}
+
+	if err := errors.Join(next.buckets.Validate(), next.dates.Validate()); err != nil {
+		return fmt.Errorf("couldn't read report parameters: %w", err)
+	}
+
+	*params = next
+	return nil
+}
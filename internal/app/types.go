@@ -0,0 +1,114 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"coursework/internal/frontend"
+	"coursework/internal/models"
+	"coursework/internal/storage"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// manageOrderTypes shows the order type catalogue and changes it until the
+// user quits.
+func manageOrderTypes(ctx context.Context, writer io.Writer, reader *bufio.Reader, renderer frontend.Renderer, controller storage.Store) error {
+	for {
+		types, err := controller.OrderTypes(ctx, true)
+		if err != nil {
+			return fmt.Errorf("couldn't list order types: %w", err)
+		}
+		fmt.Fprintln(writer)
+		if err = frontend.PrintOrderTypes(writer, renderer, types); err != nil {
+			return err
+		}
+
+		choice, err := frontend.TakeInput(writer, reader, frontend.TypeActions)
+		if err != nil {
+			return err
+		}
+
+		switch choice {
+		case "a":
+			err = addOrderType(ctx, writer, reader, controller)
+		case "r":
+			err = renameOrderType(ctx, writer, reader, controller)
+		case "d":
+			err = deactivateOrderType(ctx, writer, reader, controller)
+		case "q", "":
+			return nil
+		default:
+			fmt.Fprintln(writer, frontend.InvalidChoice)
+			continue
+		}
+
+		if errors.Is(err, storage.ErrConflict) {
+			fmt.Fprintln(writer, "There already is an order type with this code.")
+		} else {
+			handleError(writer, err)
+		}
+	}
+}
+
+func addOrderType(ctx context.Context, writer io.Writer, reader *bufio.Reader, controller storage.Store) error {
+	var orderType models.OrderType
+
+	fields := []struct {
+		prompt string
+		dest   *string
+	}{
+		{"Code (in lower case, stored in the orders): ", &orderType.Code},
+		{"Ukrainian name: ", &orderType.NameUk},
+		{"English name: ", &orderType.NameEn},
+	}
+	for _, field := range fields {
+		value, err := frontend.TakeInput(writer, reader, field.prompt)
+		if err != nil {
+			return fmt.Errorf("couldn't add order type: %w", err)
+		}
+		*field.dest = value
+	}
+
+	if err := controller.AddOrderType(ctx, orderType); err != nil {
+		return fmt.Errorf("couldn't add order type: %w", err)
+	}
+	fmt.Fprintf(writer, "\nOrder type %s added successfully\n", orderType.Code)
+	return nil
+}
+
+func renameOrderType(ctx context.Context, writer io.Writer, reader *bufio.Reader, controller storage.Store) error {
+	code, err := frontend.TakeInput(writer, reader, "Code: ")
+	if err != nil {
+		return fmt.Errorf("couldn't rename order type: %w", err)
+	}
+
+	fmt.Fprintln(writer, "Press Enter to keep the current name.")
+	nameUk, err := frontend.TakeInput(writer, reader, "Ukrainian name: ")
+	if err != nil {
+		return fmt.Errorf("couldn't rename order type: %w", err)
+	}
+	nameEn, err := frontend.TakeInput(writer, reader, "English name: ")
+	if err != nil {
+		return fmt.Errorf("couldn't rename order type: %w", err)
+	}
+
+	if err = controller.RenameOrderType(ctx, code, nameUk, nameEn); err != nil {
+		return fmt.Errorf("couldn't rename order type: %w", err)
+	}
+	fmt.Fprintf(writer, "\nOrder type %s renamed successfully\n", code)
+	return nil
+}
+
+func deactivateOrderType(ctx context.Context, writer io.Writer, reader *bufio.Reader, controller storage.Store) error {
+	code, err := frontend.TakeInput(writer, reader, "Code: ")
+	if err != nil {
+		return fmt.Errorf("couldn't deactivate order type: %w", err)
+	}
+
+	if err = controller.DeactivateOrderType(ctx, code); err != nil {
+		return fmt.Errorf("couldn't deactivate order type: %w", err)
+	}
+	fmt.Fprintf(writer, "\nOrder type %s deactivated successfully\n", code)
+	return nil
+}
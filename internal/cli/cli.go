@@ -0,0 +1,1098 @@
+package cli
+
+import (
+	"context"
+	"coursework/internal/api"
+	"coursework/internal/config"
+	"coursework/internal/export"
+	"coursework/internal/frontend"
+	"coursework/internal/importer"
+	"coursework/internal/models"
+	"coursework/internal/nbu"
+	"coursework/internal/storage"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK       = 0
+	ExitFailure  = 1
+	ExitUsage    = 2
+	ExitNotFound = 3
+	ExitConflict = 4
+	// ExitInterrupted is the code of a command stopped by a signal.
+	ExitInterrupted = 130
+)
+
+const usage = `Usage:
+  orders list [--limit N] [--from T] [--to T] [--type T] [--currency C] [--min-amount X] [--max-amount X] [--uah]
+              [--sort FIELD] [--desc] [--after CURSOR | --before CURSOR]
+  orders add --at "YYYY-MM-DD hh:mm:ss" --type T --amount X --currency C [--rate R]
+  orders import [--dry-run] [--columns field=column,...] [--time-format F] [--delimiter C] FILE
+  orders update-type --id N --type T
+  orders edit --id N [--at "YYYY-MM-DD hh:mm:ss"] [--type T] [--amount X] [--currency C] [--rate R]
+  orders delete --id N
+  orders deleted
+  orders restore --id N
+  orders purge --older-than 30d
+  report biggest-dates [--limit N]
+  report rate-changes [--official]
+  report small-orders [--type T] [--threshold X]
+  report smallest-types [--limit N]
+  report periods [--bucket-minutes N | --shift name=HH:MM-HH:MM...] [--tz ZONE] [--big-sale X]
+  report revenue [--by day|week|month]
+  report breakdown
+  types list [--all]
+  types add --code C --uk NAME --en NAME
+  types rename --code C [--uk NAME] [--en NAME]
+  types deactivate --code C
+  rates load FILE
+  export [--format csv|json|xlsx] [--output FILE] [--from T] [--to T] [report flags] all|orders|biggest-dates|rate-changes|periods|revenue|breakdown|smallest-types|small-orders|small-orders-by-month...
+  history order --id N
+  history changes --from "YYYY-MM-DD hh:mm:ss" [--to "YYYY-MM-DD hh:mm:ss"]
+  serve [--addr :8080]
+
+Commands that print orders, reports or history accept --format text|markdown|json|csv,
+defaulting to the configured format.
+Reports accept --from T and --to T to only look at orders placed in [from, to).
+Report flags not given default to the configured reports settings. Export takes
+the flags of every report, with --biggest-limit and --smallest-limit for the limits.
+`
+
+// errUsage marks errors caused by a malformed command line.
+var errUsage = errors.New("invalid usage")
+
+type command struct {
+	stdout io.Writer
+	stderr io.Writer
+	store  storage.Store
+	// renderer prints results unless --format picks another one.
+	renderer frontend.Renderer
+	// defaults are the parameters of reports run without them.
+	defaults config.Reports
+}
+
+// Run executes the subcommand in args against store and returns the process
+// exit code. Results go to stdout, diagnostics to stderr. Cancelling ctx stops
+// the command. Results are printed with renderer unless --format says
+// otherwise and report flags default to defaults.
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer, store storage.Store, renderer frontend.Renderer, defaults config.Reports) int {
+	c := &command{stdout: stdout, stderr: stderr, store: store, renderer: renderer, defaults: defaults}
+
+	err := c.dispatch(ctx, args)
+	var invalid *storage.ValidationError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, flag.ErrHelp):
+		fmt.Fprint(stderr, usage)
+		return ExitOK
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(stderr, "%v\n\n%s", err, usage)
+		return ExitUsage
+	case errors.As(err, &invalid):
+		fmt.Fprintln(stderr, invalid)
+		return ExitUsage
+	case errors.Is(err, storage.ErrOrderNotFound), errors.Is(err, storage.ErrOrderTypeNotFound):
+		fmt.Fprintln(stderr, err)
+		return ExitNotFound
+	case errors.Is(err, storage.ErrConflict):
+		fmt.Fprintln(stderr, err)
+		return ExitConflict
+	case errors.Is(err, context.Canceled):
+		fmt.Fprintln(stderr, "cancelled")
+		return ExitInterrupted
+	default:
+		slog.Error("command failed", "args", args, "error", err)
+		fmt.Fprintln(stderr, err)
+		return ExitFailure
+	}
+}
+
+func (c *command) dispatch(ctx context.Context, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%w: missing command", errUsage)
+	}
+
+	switch args[0] {
+	case "orders":
+		if len(args) < 2 {
+			return fmt.Errorf("%w: missing orders subcommand", errUsage)
+		}
+		switch args[1] {
+		case "list":
+			return c.listOrders(ctx, args[2:])
+		case "add":
+			return c.addOrder(ctx, args[2:])
+		case "import":
+			return c.importOrders(ctx, args[2:])
+		case "update-type":
+			return c.updateOrderType(ctx, args[2:])
+		case "edit":
+			return c.editOrder(ctx, args[2:])
+		case "delete":
+			return c.deleteOrder(ctx, args[2:])
+		case "deleted":
+			return c.deletedOrders(ctx, args[2:])
+		case "restore":
+			return c.restoreOrder(ctx, args[2:])
+		case "purge":
+			return c.purgeOrders(ctx, args[2:])
+		}
+		return fmt.Errorf("%w: unknown orders subcommand %q", errUsage, args[1])
+	case "report":
+		if len(args) < 2 {
+			return fmt.Errorf("%w: missing report name", errUsage)
+		}
+		switch args[1] {
+		case "biggest-dates":
+			return c.biggestDates(ctx, args[2:])
+		case "rate-changes":
+			return c.rateChanges(ctx, args[2:])
+		case "small-orders":
+			return c.smallOrders(ctx, args[2:])
+		case "smallest-types":
+			return c.smallestTypes(ctx, args[2:])
+		case "periods":
+			return c.periods(ctx, args[2:])
+		case "revenue":
+			return c.revenue(ctx, args[2:])
+		case "breakdown":
+			return c.breakdown(ctx, args[2:])
+		}
+		return fmt.Errorf("%w: unknown report %q", errUsage, args[1])
+	case "types":
+		if len(args) < 2 {
+			return fmt.Errorf("%w: missing types subcommand", errUsage)
+		}
+		switch args[1] {
+		case "list":
+			return c.listOrderTypes(ctx, args[2:])
+		case "add":
+			return c.addOrderType(ctx, args[2:])
+		case "rename":
+			return c.renameOrderType(ctx, args[2:])
+		case "deactivate":
+			return c.deactivateOrderType(ctx, args[2:])
+		}
+		return fmt.Errorf("%w: unknown types subcommand %q", errUsage, args[1])
+	case "rates":
+		if len(args) < 2 || args[1] != "load" {
+			return fmt.Errorf("%w: expected rates load FILE", errUsage)
+		}
+		return c.loadRates(ctx, args[2:])
+	case "history":
+		if len(args) < 2 {
+			return fmt.Errorf("%w: missing history subcommand", errUsage)
+		}
+		switch args[1] {
+		case "order":
+			return c.orderHistory(ctx, args[2:])
+		case "changes":
+			return c.changesBetween(ctx, args[2:])
+		}
+		return fmt.Errorf("%w: unknown history subcommand %q", errUsage, args[1])
+	case "export":
+		return c.export(ctx, args[1:])
+	case "serve":
+		return c.serve(ctx, args[1:])
+	case "help", "-h", "--help":
+		return flag.ErrHelp
+	}
+
+	return fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+}
+
+func (c *command) listOrders(ctx context.Context, args []string) error {
+	flags := newFlagSet("orders list")
+	query := orderQueryFlags(flags, c.store.Location())
+	renderer := c.rendererFlag(flags)
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if err := query.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	page, err := c.store.ListOrders(ctx, *query)
+	if err != nil {
+		return err
+	}
+
+	if err = frontend.PrintTable(c.stdout, *renderer, page.Orders); err != nil {
+		return err
+	}
+
+	if page.Prev != "" {
+		fmt.Fprintf(c.stderr, "previous page: --before %s\n", page.Prev)
+	}
+	if page.Next != "" {
+		fmt.Fprintf(c.stderr, "next page: --after %s\n", page.Next)
+	}
+	return nil
+}
+
+func (c *command) addOrder(ctx context.Context, args []string) error {
+	flags := newFlagSet("orders add")
+	at := flags.String("at", "", "date and time of the order ("+frontend.TimeFormat+")")
+	orderType := flags.String("type", "", "order type")
+	amount := decimalFlag(flags, "amount", decimal.Zero, "pay amount")
+	currency := flags.String("currency", "", "currency code")
+	rate := decimalFlag(flags, "rate", decimal.Zero, "exchange rate to UAH, the official one if omitted")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if *at == "" || *orderType == "" || *currency == "" {
+		return fmt.Errorf("%w: --at, --type and --currency are required", errUsage)
+	}
+
+	timeStamp, err := time.ParseInLocation(frontend.TimeFormat, *at, c.store.Location())
+	if err != nil {
+		return fmt.Errorf("%w: --at: %v", errUsage, err)
+	}
+
+	return c.store.AddNewOrder(ctx, timeStamp, *orderType, *amount, *currency, *rate)
+}
+
+func (c *command) importOrders(ctx context.Context, args []string) error {
+	opts := importer.Options{Mapping: importer.DefaultMapping}
+
+	flags := newFlagSet("orders import")
+	dryRun := flags.Bool("dry-run", false, "only report the rows that would be rejected")
+	flags.Func("columns", "CSV columns of the order fields, e.g. timestamp=Date,amount=Sum", func(raw string) error {
+		mapping, err := importer.ParseMapping(raw)
+		opts.Mapping = mapping
+		return err
+	})
+	flags.StringVar(&opts.TimeFormat, "time-format", frontend.TimeFormat, "layout of the timestamp column")
+	flags.Func("delimiter", "field separator, a comma by default", func(raw string) error {
+		if utf8.RuneCountInString(raw) != 1 {
+			return fmt.Errorf("expected a single character, got %q", raw)
+		}
+		opts.Comma, _ = utf8.DecodeRuneInString(raw)
+		return nil
+	})
+	name, err := parseFile(flags, args)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	opts.Location = c.store.Location()
+	opts.RateAt = func(currency string, at time.Time) (decimal.Decimal, error) {
+		return c.store.RateAt(ctx, currency, at)
+	}
+	opts.Validate = func(order models.Order) error {
+		return c.store.ValidateOrder(ctx, order)
+	}
+	result, err := importer.ParseCSV(file, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, rejected := range result.Rejected {
+		fmt.Fprintln(c.stdout, rejected.Error())
+	}
+
+	if *dryRun {
+		fmt.Fprintf(c.stdout, "would import %d orders, %d rows rejected\n", len(result.Orders), len(result.Rejected))
+		return nil
+	}
+
+	imported, err := c.store.ImportOrders(ctx, result.Orders)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "imported %d orders, %d rows rejected\n", imported, len(result.Rejected))
+	return nil
+}
+
+func (c *command) updateOrderType(ctx context.Context, args []string) error {
+	flags := newFlagSet("orders update-type")
+	id := flags.Int("id", 0, "order id")
+	orderType := flags.String("type", "", "new order type")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if *id == 0 || *orderType == "" {
+		return fmt.Errorf("%w: --id and --type are required", errUsage)
+	}
+
+	return c.store.UpdateOrder(ctx, *id, *orderType)
+}
+
+func (c *command) editOrder(ctx context.Context, args []string) error {
+	var patch models.OrderPatch
+
+	flags := newFlagSet("orders edit")
+	id := flags.Int("id", 0, "order id")
+	flags.Func("at", "new date and time of the order ("+frontend.TimeFormat+")", func(raw string) error {
+		timeStamp, err := time.ParseInLocation(frontend.TimeFormat, raw, c.store.Location())
+		patch.TimeStamp = &timeStamp
+		return err
+	})
+	flags.Func("type", "new order type", func(raw string) error {
+		patch.Type = &raw
+		return nil
+	})
+	flags.Func("amount", "new pay amount", func(raw string) error {
+		amount, err := decimal.NewFromString(raw)
+		patch.Amount = &amount
+		return err
+	})
+	flags.Func("currency", "new currency code", func(raw string) error {
+		patch.Currency = &raw
+		return nil
+	})
+	flags.Func("rate", "new exchange rate to UAH", func(raw string) error {
+		rate, err := decimal.NewFromString(raw)
+		patch.ExchangeRate = &rate
+		return err
+	})
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if *id == 0 {
+		return fmt.Errorf("%w: --id is required", errUsage)
+	}
+
+	return c.store.PatchOrder(ctx, *id, patch)
+}
+
+func (c *command) deleteOrder(ctx context.Context, args []string) error {
+	flags := newFlagSet("orders delete")
+	id := flags.Int("id", 0, "order id")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if *id == 0 {
+		return fmt.Errorf("%w: --id is required", errUsage)
+	}
+
+	return c.store.DeleteOrder(ctx, *id)
+}
+
+func (c *command) deletedOrders(ctx context.Context, args []string) error {
+	flags := newFlagSet("orders deleted")
+	renderer := c.rendererFlag(flags)
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	orders, err := c.store.DeletedOrders(ctx)
+	if err != nil {
+		return err
+	}
+
+	return frontend.PrintTable(c.stdout, *renderer, orders)
+}
+
+func (c *command) restoreOrder(ctx context.Context, args []string) error {
+	flags := newFlagSet("orders restore")
+	id := flags.Int("id", 0, "id of the deleted order")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if *id == 0 {
+		return fmt.Errorf("%w: --id is required", errUsage)
+	}
+
+	return c.store.RestoreOrder(ctx, *id)
+}
+
+func (c *command) purgeOrders(ctx context.Context, args []string) error {
+	flags := newFlagSet("orders purge")
+	olderThan := flags.String("older-than", "", "retention period, in days (30d) or as a duration (12h)")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if *olderThan == "" {
+		return fmt.Errorf("%w: --older-than is required", errUsage)
+	}
+	retention, err := parseRetention(*olderThan)
+	if err != nil {
+		return fmt.Errorf("%w: --older-than: %v", errUsage, err)
+	}
+
+	purged, err := c.store.PurgeDeletedOrders(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "purged %d orders\n", purged)
+	return nil
+}
+
+func (c *command) biggestDates(ctx context.Context, args []string) error {
+	flags := newFlagSet("report biggest-dates")
+	opts := newReportOptions(c.defaults)
+	flags.IntVar(&opts.biggestLimit, "limit", opts.biggestLimit, "number of dates to print")
+	dates := dateRangeFlags(flags, c.store.Location())
+	renderer := c.rendererFlag(flags)
+	if err := parseReport(flags, args, dates); err != nil {
+		return err
+	}
+
+	orders, err := c.store.DatesWithBiggestOrders(ctx, opts.biggestLimit, *dates)
+	if err != nil {
+		return err
+	}
+
+	return frontend.PrintBiggestOrders(c.stdout, *renderer, orders)
+}
+
+func (c *command) rateChanges(ctx context.Context, args []string) error {
+	flags := newFlagSet("report rate-changes")
+	official := flags.Bool("official", false, "use days when the official rate changed")
+	dates := dateRangeFlags(flags, c.store.Location())
+	renderer := c.rendererFlag(flags)
+	if err := parseReport(flags, args, dates); err != nil {
+		return err
+	}
+
+	var orders []models.Order
+	var err error
+	if *official {
+		orders, err = c.store.OrdersWhenOfficialRateChanged(ctx, *dates)
+	} else {
+		orders, err = c.store.OrdersWhenRateChanged(ctx, *dates)
+	}
+	if err != nil {
+		return err
+	}
+
+	return frontend.PrintTable(c.stdout, *renderer, orders)
+}
+
+func (c *command) smallOrders(ctx context.Context, args []string) error {
+	flags := newFlagSet("report small-orders")
+	opts := newReportOptions(c.defaults)
+	opts.smallOrdersFlags(flags)
+	dates := dateRangeFlags(flags, c.store.Location())
+	renderer := c.rendererFlag(flags)
+	if err := parseReport(flags, args, dates); err != nil {
+		return err
+	}
+
+	distribution, err := c.smallOrdersDistribution(ctx, opts, *dates)
+	if err != nil {
+		return err
+	}
+
+	return frontend.PrintSmallOrders(c.stdout, *renderer, distribution)
+}
+
+func (c *command) smallestTypes(ctx context.Context, args []string) error {
+	flags := newFlagSet("report smallest-types")
+	opts := newReportOptions(c.defaults)
+	flags.IntVar(&opts.smallestLimit, "limit", opts.smallestLimit, "number of smallest orders to look at")
+	dates := dateRangeFlags(flags, c.store.Location())
+	renderer := c.rendererFlag(flags)
+	if err := parseReport(flags, args, dates); err != nil {
+		return err
+	}
+
+	orderTypes, err := c.store.TypeOfSmallestOrders(ctx, opts.smallestLimit, *dates)
+	if err != nil {
+		return err
+	}
+
+	return frontend.PrintTypes(c.stdout, *renderer, orderTypes)
+}
+
+func (c *command) periods(ctx context.Context, args []string) error {
+	flags := newFlagSet("report periods")
+	opts := newReportOptions(c.defaults)
+	opts.bucketFlags(flags)
+	dates := dateRangeFlags(flags, c.store.Location())
+	renderer := c.rendererFlag(flags)
+	if err := parseReport(flags, args, dates); err != nil {
+		return err
+	}
+	if err := opts.buckets.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	stats, err := c.store.GetTableForPeriods(ctx, opts.buckets, *dates)
+	if err != nil {
+		return err
+	}
+
+	return frontend.PrintStats(c.stdout, *renderer, stats)
+}
+
+func (c *command) revenue(ctx context.Context, args []string) error {
+	flags := newFlagSet("report revenue")
+	opts := newReportOptions(c.defaults)
+	opts.granularityFlag(flags)
+	dates := dateRangeFlags(flags, c.store.Location())
+	renderer := c.rendererFlag(flags)
+	if err := parseReport(flags, args, dates); err != nil {
+		return err
+	}
+
+	points, err := c.store.RevenueSeries(ctx, opts.granularity, *dates)
+	if err != nil {
+		return err
+	}
+
+	return frontend.PrintRevenue(c.stdout, *renderer, points)
+}
+
+func (c *command) breakdown(ctx context.Context, args []string) error {
+	flags := newFlagSet("report breakdown")
+	dates := dateRangeFlags(flags, c.store.Location())
+	renderer := c.rendererFlag(flags)
+	if err := parseReport(flags, args, dates); err != nil {
+		return err
+	}
+
+	b, err := c.store.Breakdown(ctx, *dates)
+	if err != nil {
+		return err
+	}
+
+	return frontend.PrintBreakdown(c.stdout, *renderer, b)
+}
+
+func (c *command) listOrderTypes(ctx context.Context, args []string) error {
+	flags := newFlagSet("types list")
+	all := flags.Bool("all", false, "include deactivated types")
+	renderer := c.rendererFlag(flags)
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	types, err := c.store.OrderTypes(ctx, *all)
+	if err != nil {
+		return err
+	}
+
+	return frontend.PrintOrderTypes(c.stdout, *renderer, types)
+}
+
+func (c *command) addOrderType(ctx context.Context, args []string) error {
+	var orderType models.OrderType
+
+	flags := newFlagSet("types add")
+	flags.StringVar(&orderType.Code, "code", "", "code stored in the orders, in lower case")
+	flags.StringVar(&orderType.NameUk, "uk", "", "Ukrainian display name")
+	flags.StringVar(&orderType.NameEn, "en", "", "English display name")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if orderType.Code == "" {
+		return fmt.Errorf("%w: --code is required", errUsage)
+	}
+
+	return c.store.AddOrderType(ctx, orderType)
+}
+
+func (c *command) renameOrderType(ctx context.Context, args []string) error {
+	flags := newFlagSet("types rename")
+	code := flags.String("code", "", "code of the type")
+	nameUk := flags.String("uk", "", "new Ukrainian display name")
+	nameEn := flags.String("en", "", "new English display name")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if *code == "" {
+		return fmt.Errorf("%w: --code is required", errUsage)
+	}
+
+	return c.store.RenameOrderType(ctx, *code, *nameUk, *nameEn)
+}
+
+func (c *command) deactivateOrderType(ctx context.Context, args []string) error {
+	flags := newFlagSet("types deactivate")
+	code := flags.String("code", "", "code of the type")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if *code == "" {
+		return fmt.Errorf("%w: --code is required", errUsage)
+	}
+
+	return c.store.DeactivateOrderType(ctx, *code)
+}
+
+func (c *command) loadRates(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: rates load expects one file", errUsage)
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rates, err := nbu.ParseCSV(file)
+	if err != nil {
+		return err
+	}
+
+	loaded, err := c.store.AddRates(ctx, rates)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "loaded %d rates\n", loaded)
+	return nil
+}
+
+func (c *command) orderHistory(ctx context.Context, args []string) error {
+	flags := newFlagSet("history order")
+	id := flags.Int("id", 0, "order id")
+	renderer := c.rendererFlag(flags)
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if *id == 0 {
+		return fmt.Errorf("%w: --id is required", errUsage)
+	}
+
+	changes, err := c.store.OrderHistory(ctx, *id)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return fmt.Errorf("no history for order %d: %w", *id, storage.ErrOrderNotFound)
+	}
+
+	return frontend.PrintHistory(c.stdout, *renderer, changes)
+}
+
+func (c *command) changesBetween(ctx context.Context, args []string) error {
+	flags := newFlagSet("history changes")
+	from := timeFlag(flags, c.store.Location(), "from", time.Time{}, "start of the window ("+frontend.TimeFormat+")")
+	to := timeFlag(flags, c.store.Location(), "to", time.Now(), "end of the window, now by default")
+	renderer := c.rendererFlag(flags)
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if from.IsZero() {
+		return fmt.Errorf("%w: --from is required", errUsage)
+	}
+
+	changes, err := c.store.ChangesBetween(ctx, *from, *to)
+	if err != nil {
+		return err
+	}
+
+	return frontend.PrintHistory(c.stdout, *renderer, changes)
+}
+
+// exportTables lists what the export command accepts, in the order of "all".
+var exportTables = []string{"orders", "biggest-dates", "rate-changes", "periods", "revenue", "breakdown", "smallest-types", "small-orders", "small-orders-by-month"}
+
+func (c *command) export(ctx context.Context, args []string) error {
+	flags := newFlagSet("export")
+	rawFormat := flags.String("format", "csv", "csv, json or xlsx")
+	output := flags.String("output", "", "file to write, stdout by default")
+	dates := dateRangeFlags(flags, c.store.Location())
+	opts := newReportOptions(c.defaults)
+	opts.allFlags(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := dates.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if err := opts.buckets.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	format, err := export.ParseFormat(*rawFormat)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	names := flags.Args()
+	if len(names) == 1 && names[0] == "all" {
+		names = exportTables
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("%w: nothing to export", errUsage)
+	}
+	if format == export.CSV && len(names) > 1 {
+		return fmt.Errorf("%w: csv holds one table, export them one at a time", errUsage)
+	}
+
+	tables := make([]export.Table, 0, len(names))
+	for _, name := range names {
+		table, err := c.exportTable(ctx, name, opts, *dates)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, table)
+	}
+
+	if *output == "" {
+		return export.Write(c.stdout, format, tables...)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err = export.Write(file, format, tables...); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// exportTable runs the query behind name with the parameters in opts,
+// limited to orders placed within dates.
+func (c *command) exportTable(ctx context.Context, name string, opts *reportOptions, dates models.DateRange) (export.Table, error) {
+	switch name {
+	case "orders":
+		page, err := c.store.ListOrders(ctx, models.OrderQuery{Filter: models.OrderFilter{From: dates.From, To: dates.To}})
+		return export.Orders(name, page.Orders), err
+	case "biggest-dates":
+		orders, err := c.store.DatesWithBiggestOrders(ctx, opts.biggestLimit, dates)
+		return export.BiggestOrders(orders), err
+	case "rate-changes":
+		orders, err := c.store.OrdersWhenRateChanged(ctx, dates)
+		return export.Orders(name, orders), err
+	case "periods":
+		stats, err := c.store.GetTableForPeriods(ctx, opts.buckets, dates)
+		return export.PeriodStats(stats), err
+	case "revenue":
+		points, err := c.store.RevenueSeries(ctx, opts.granularity, dates)
+		return export.Revenue(points), err
+	case "breakdown":
+		b, err := c.store.Breakdown(ctx, dates)
+		return export.Breakdown(b), err
+	case "smallest-types":
+		orderTypes, err := c.store.TypeOfSmallestOrders(ctx, opts.smallestLimit, dates)
+		return export.SmallestTypes(orderTypes), err
+	case "small-orders":
+		distribution, err := c.smallOrdersDistribution(ctx, opts, dates)
+		return export.SmallOrders(distribution), err
+	case "small-orders-by-month":
+		distribution, err := c.smallOrdersDistribution(ctx, opts, dates)
+		return export.MonthlyCounts(distribution), err
+	}
+
+	return export.Table{}, fmt.Errorf("%w: unknown export table %q", errUsage, name)
+}
+
+// smallOrdersDistribution is the distribution behind the small orders
+// report, of the type and threshold in opts.
+func (c *command) smallOrdersDistribution(ctx context.Context, opts *reportOptions, dates models.DateRange) (models.MonthlyDistribution, error) {
+	return c.store.MonthlyDistribution(ctx, opts.orderType, opts.threshold, dates)
+}
+
+func (c *command) serve(ctx context.Context, args []string) error {
+	flags := newFlagSet("serve")
+	addr := flags.String("addr", ":8080", "address for the HTTP API to listen on")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	slog.Info("Starting HTTP API", "addr", *addr)
+	fmt.Fprintf(c.stderr, "Serving HTTP API on %s\n", *addr)
+
+	server := &http.Server{Addr: *addr, Handler: api.NewHandler(c.store, c.defaults)}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		// let the requests in flight finish, for a while
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+// orderQueryFlags defines the filter, sort and paging flags of order listings.
+// Times are read in location.
+func orderQueryFlags(flags *flag.FlagSet, location *time.Location) *models.OrderQuery {
+	query := &models.OrderQuery{}
+	f := &query.Filter
+
+	flags.IntVar(&query.Limit, "limit", 0, "page size, 0 prints all orders")
+	flags.Func("from", "earliest order time ("+frontend.TimeFormat+")", func(raw string) (err error) {
+		f.From, err = time.ParseInLocation(frontend.TimeFormat, raw, location)
+		return err
+	})
+	flags.Func("to", "order time to list orders before ("+frontend.TimeFormat+")", func(raw string) (err error) {
+		f.To, err = time.ParseInLocation(frontend.TimeFormat, raw, location)
+		return err
+	})
+	flags.StringVar(&f.Type, "type", "", "order type")
+	flags.StringVar(&f.Currency, "currency", "", "currency code")
+	flags.Func("min-amount", "smallest amount", func(raw string) error {
+		amount, err := decimal.NewFromString(raw)
+		f.MinAmount = &amount
+		return err
+	})
+	flags.Func("max-amount", "biggest amount", func(raw string) error {
+		amount, err := decimal.NewFromString(raw)
+		f.MaxAmount = &amount
+		return err
+	})
+	flags.BoolVar(&f.InUah, "uah", false, "compare amounts in UAH rather than the order currency")
+	flags.Func("sort", fmt.Sprintf("column to sort by, one of %v", models.SortFields), func(raw string) (err error) {
+		query.Sort, err = models.ParseSortField(raw)
+		return err
+	})
+	flags.BoolVar(&query.Desc, "desc", false, "sort in descending order")
+	flags.Func("after", "cursor of the page to continue after", func(raw string) error {
+		cursor, err := models.DecodeCursor(raw)
+		query.After = &cursor
+		return err
+	})
+	flags.Func("before", "cursor of the page to go back from", func(raw string) error {
+		cursor, err := models.DecodeCursor(raw)
+		query.Before = &cursor
+		return err
+	})
+
+	return query
+}
+
+// dateRangeFlags defines the --from and --to flags limiting a report to the
+// orders placed in between, written as times in location.
+func dateRangeFlags(flags *flag.FlagSet, location *time.Location) *models.DateRange {
+	dates := &models.DateRange{}
+	flags.Func("from", "earliest order time ("+frontend.TimeFormat+")", func(raw string) (err error) {
+		dates.From, err = time.ParseInLocation(frontend.TimeFormat, raw, location)
+		return err
+	})
+	flags.Func("to", "order time to stop before ("+frontend.TimeFormat+")", func(raw string) (err error) {
+		dates.To, err = time.ParseInLocation(frontend.TimeFormat, raw, location)
+		return err
+	})
+	return dates
+}
+
+// reportOptions are the parameters of the reports, starting from the
+// configured defaults. The report commands define flags for the ones they
+// take, export for all of them.
+type reportOptions struct {
+	biggestLimit  int
+	smallestLimit int
+	orderType     string
+	threshold     decimal.Decimal
+	buckets       models.Buckets
+	granularity   models.Granularity
+}
+
+func newReportOptions(defaults config.Reports) *reportOptions {
+	return &reportOptions{
+		biggestLimit:  defaults.BiggestLimit,
+		smallestLimit: defaults.SmallestLimit,
+		orderType:     defaults.OrderType,
+		threshold:     defaults.LessThan,
+		buckets:       defaults.Buckets(),
+		granularity:   defaults.Granularity,
+	}
+}
+
+// allFlags defines the flags of every report. The limits of the two reports
+// taking one are named after them.
+func (o *reportOptions) allFlags(flags *flag.FlagSet) {
+	flags.IntVar(&o.biggestLimit, "biggest-limit", o.biggestLimit, "number of dates with the biggest orders")
+	flags.IntVar(&o.smallestLimit, "smallest-limit", o.smallestLimit, "number of smallest orders to look at")
+	o.smallOrdersFlags(flags)
+	o.bucketFlags(flags)
+	o.granularityFlag(flags)
+}
+
+// smallOrdersFlags defines the --type and --threshold flags of the small
+// orders report.
+func (o *reportOptions) smallOrdersFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.orderType, "type", o.orderType, "order type")
+	decimalVar(flags, &o.threshold, "threshold", "upper bound of the order amount in UAH")
+}
+
+// bucketFlags defines the flags splitting the day in the periods report. The
+// buckets still need to be validated once they are parsed.
+func (o *reportOptions) bucketFlags(flags *flag.FlagSet) {
+	b := &o.buckets
+	flags.IntVar(&b.Minutes, "bucket-minutes", b.Minutes, "width of a period in minutes")
+	flags.Func("shift", "named period used instead of equal ones, as name=HH:MM-HH:MM; repeatable", func(raw string) error {
+		shift, err := models.ParseShift(raw)
+		b.Shifts = append(b.Shifts, shift)
+		return err
+	})
+	flags.Func("tz", "time zone to split the day in, the business one by default", func(raw string) (err error) {
+		b.Location, err = time.LoadLocation(raw)
+		return err
+	})
+	decimalVar(flags, &b.BigSale, "big-sale", "amount in UAH above which an order is a big sale")
+}
+
+// granularityFlag defines the --by flag of the revenue report.
+func (o *reportOptions) granularityFlag(flags *flag.FlagSet) {
+	flags.Func("by", "length of a period: day, week or month", func(raw string) (err error) {
+		o.granularity, err = models.ParseGranularity(raw)
+		return err
+	})
+}
+
+// rendererFlag defines the --format flag choosing how a command prints its
+// results instead of c.renderer.
+func (c *command) rendererFlag(flags *flag.FlagSet) *frontend.Renderer {
+	renderer := c.renderer
+	flags.Func("format", "output format: "+strings.Join(frontend.Formats, ", "), func(raw string) error {
+		r, err := frontend.NewRenderer(raw)
+		if err != nil {
+			return err
+		}
+		renderer = r
+		return nil
+	})
+	return &renderer
+}
+
+// decimalFlag defines a flag holding an exact decimal number.
+func decimalFlag(flags *flag.FlagSet, name string, value decimal.Decimal, usage string) *decimal.Decimal {
+	p := &value
+	decimalVar(flags, p, name, usage)
+	return p
+}
+
+// decimalVar defines a flag storing an exact decimal number in p, which holds
+// the default.
+func decimalVar(flags *flag.FlagSet, p *decimal.Decimal, name, usage string) {
+	flags.Func(name, usage, func(raw string) error {
+		d, err := decimal.NewFromString(raw)
+		if err != nil {
+			return err
+		}
+		*p = d
+		return nil
+	})
+}
+
+// timeFlag defines a flag holding a time in location written in
+// frontend.TimeFormat.
+func timeFlag(flags *flag.FlagSet, location *time.Location, name string, value time.Time, usage string) *time.Time {
+	p := &value
+	flags.Func(name, usage, func(raw string) error {
+		t, err := time.ParseInLocation(frontend.TimeFormat, raw, location)
+		if err != nil {
+			return err
+		}
+		*p = t
+		return nil
+	})
+	return p
+}
+
+// parseRetention reads a period written as a number of days ("30d") or as a
+// time.Duration ("36h").
+func parseRetention(raw string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number of days %q", raw)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	retention, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, err
+	}
+	if retention < 0 {
+		return 0, fmt.Errorf("negative retention %q", raw)
+	}
+
+	return retention, nil
+}
+
+func parse(flags *flag.FlagSet, args []string) error {
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: %s: unexpected argument %q", errUsage, flags.Name(), flags.Arg(0))
+	}
+
+	return nil
+}
+
+// parseReport is parse for reports, which also checks the date range.
+func parseReport(flags *flag.FlagSet, args []string, dates *models.DateRange) error {
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if err := dates.Validate(); err != nil {
+		return fmt.Errorf("%w: %s: %v", errUsage, flags.Name(), err)
+	}
+
+	return nil
+}
+
+// parseFile parses flags followed by a single file name and returns the name.
+func parseFile(flags *flag.FlagSet, args []string) (string, error) {
+	if err := parseFlags(flags, args); err != nil {
+		return "", err
+	}
+
+	if flags.NArg() != 1 {
+		return "", fmt.Errorf("%w: %s expects one file", errUsage, flags.Name())
+	}
+
+	return flags.Arg(0), nil
+}
+
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", errUsage, flags.Name(), err)
+	}
+
+	return nil
+}
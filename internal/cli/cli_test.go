@@ -0,0 +1,289 @@
+package cli
+
+import (
+	"bytes"
+	"coursework/internal/config"
+	"coursework/internal/frontend"
+	"coursework/internal/memory"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRun(t *testing.T) {
+	store := memory.NewStore()
+
+	cases := []struct {
+		name   string
+		args   string
+		want   int
+		stdout string
+	}{
+		{"iso timestamp", `orders add --at=2025-12-01T09:30:00 --type харчування --amount 20 --currency UAH --rate 1`, ExitUsage, ""},
+		{"malformed timestamp", `orders add --at 2025-12-01_09:30:00 --type харчування --amount 20 --currency UAH --rate 1`, ExitUsage, ""},
+		{"missing flags", `orders add --type харчування`, ExitUsage, ""},
+		{"unknown command", `orders frobnicate`, ExitUsage, ""},
+		{"update missing order", `orders update-type --id 7 --type одяг`, ExitNotFound, ""},
+		{"delete missing order", `orders delete --id 7`, ExitNotFound, ""},
+		{"smallest types of empty store", `report smallest-types --limit 3`, ExitOK, "type\n"},
+		{"smallest types as json", `report smallest-types --format json`, ExitOK, "[]\n"},
+		{"unknown format", `orders list --format yaml`, ExitUsage, ""},
+		{"small orders of empty store", `report small-orders --format csv`, ExitOK, "type,threshold,months,mean,median,stdDev\nхарчування,50.00,0,0.00,0.00,0.00\n\nmonth,orders\n"},
+		{"stray argument", `report periods now`, ExitUsage, ""},
+		{"zero bucket width", `report periods --bucket-minutes 0`, ExitUsage, ""},
+		{"periods of empty store", `report periods --bucket-minutes 720 --big-sale 500 --format csv`, ExitOK,
+			"timePeriod,start,end,totalSales,bigSales,smallSales\n00:00 - 12:00,00:00,12:00,0,0,0\n12:00 - 23:59,12:00,00:00,0,0,0\n"},
+		{"shifts", `report periods --shift late=22-02 --tz Europe/Kyiv --format csv`, ExitOK,
+			"timePeriod,start,end,totalSales,bigSales,smallSales\nlate,22:00,02:00,0,0,0\n"},
+		{"unknown time zone", `report periods --tz Mars/Olympus`, ExitUsage, ""},
+		{"revenue of empty store", `report revenue --by week --format json`, ExitOK, "[]\n"},
+		{"unknown revenue period", `report revenue --by year`, ExitUsage, ""},
+		{"breakdown of empty store", `report breakdown --format csv`, ExitOK, "type,orders,amountUah,share\ntotal,0,0.00,0.00\n"},
+		{"inverted date range", `report biggest-dates --from 2025-12-02_00:00:00 --to 2025-12-01_00:00:00`, ExitUsage, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			got := Run(t.Context(), strings.Fields(c.args), stdout, &bytes.Buffer{}, store, frontend.TextRenderer{}, config.Default().Reports)
+
+			if got != c.want {
+				t.Errorf("got exit code %d, want %d", got, c.want)
+			}
+			if stdout.String() != c.stdout {
+				t.Errorf("got output %q, want %q", stdout.String(), c.stdout)
+			}
+		})
+	}
+}
+
+func TestReportDefaults(t *testing.T) {
+	defaults := config.Default().Reports
+	defaults.OrderType = "одяг"
+	defaults.LessThan = decimal.NewFromInt(10)
+
+	stdout := &bytes.Buffer{}
+	args := strings.Fields("report small-orders --format csv")
+	if code := Run(t.Context(), args, stdout, &bytes.Buffer{}, memory.NewStore(), frontend.TextRenderer{}, defaults); code != ExitOK {
+		t.Fatalf("got exit code %d", code)
+	}
+	if want := "одяг,10.00,0,"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("got output %q, want it to contain %q", stdout.String(), want)
+	}
+}
+
+func TestDefaultRenderer(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	args := strings.Fields("report small-orders")
+	if code := Run(t.Context(), args, stdout, &bytes.Buffer{}, memory.NewStore(), frontend.CSVRenderer{}, config.Default().Reports); code != ExitOK {
+		t.Fatalf("got exit code %d", code)
+	}
+	if want := "харчування,50.00,0,"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("got output %q, want it to contain %q", stdout.String(), want)
+	}
+}
+
+func TestOrdersLifecycle(t *testing.T) {
+	store := memory.NewStore()
+	run := func(args ...string) (int, string) {
+		stdout := &bytes.Buffer{}
+		return Run(t.Context(), args, stdout, &bytes.Buffer{}, store, frontend.TextRenderer{}, config.Default().Reports), stdout.String()
+	}
+
+	if code, _ := run("orders", "add", "--at", "2025-12-01 09:30:00", "--type", "харчування",
+		"--amount", "20", "--currency", "UAH", "--rate", "1"); code != ExitOK {
+		t.Fatalf("add: got exit code %d", code)
+	}
+
+	if code, _ := run("orders", "update-type", "--id", "1", "--type", "одяг"); code != ExitOK {
+		t.Fatalf("update-type: got exit code %d", code)
+	}
+
+	code, out := run("report", "smallest-types")
+	if code != ExitOK || out != "type\nодяг\n" {
+		t.Errorf("smallest-types: got %d %q", code, out)
+	}
+
+	if code, _ = run("orders", "delete", "--id", "1"); code != ExitOK {
+		t.Fatalf("delete: got exit code %d", code)
+	}
+
+	code, out = run("orders", "list")
+	if code != ExitOK || strings.Count(out, "\n") != 1 {
+		t.Errorf("list: got %d %q", code, out)
+	}
+
+	code, out = run("orders", "deleted")
+	if code != ExitOK || !strings.Contains(out, "одяг") {
+		t.Errorf("deleted: got %d %q", code, out)
+	}
+
+	if code, _ = run("orders", "restore", "--id", "1"); code != ExitOK {
+		t.Fatalf("restore: got exit code %d", code)
+	}
+	if code, _ = run("orders", "restore", "--id", "1"); code != ExitConflict {
+		t.Errorf("restore twice: got exit code %d, want %d", code, ExitConflict)
+	}
+
+	if code, _ = run("orders", "delete", "--id", "1"); code != ExitOK {
+		t.Fatalf("delete: got exit code %d", code)
+	}
+	code, out = run("orders", "purge", "--older-than", "30d")
+	if code != ExitOK || out != "purged 0 orders\n" {
+		t.Errorf("purge 30d: got %d %q", code, out)
+	}
+	code, out = run("orders", "purge", "--older-than", "0s")
+	if code != ExitOK || out != "purged 1 orders\n" {
+		t.Errorf("purge 0s: got %d %q", code, out)
+	}
+	if code, _ = run("orders", "purge", "--older-than", "month"); code != ExitUsage {
+		t.Errorf("purge month: got exit code %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestOrderTypes(t *testing.T) {
+	store := memory.NewStore()
+	run := func(args string) (int, string) {
+		stdout := &bytes.Buffer{}
+		return Run(t.Context(), strings.Fields(args), stdout, &bytes.Buffer{}, store, frontend.TextRenderer{}, config.Default().Reports), stdout.String()
+	}
+
+	cases := []struct {
+		args string
+		want int
+	}{
+		{"types add --code подарунки --uk Подарунки --en Gifts", ExitOK},
+		{"types add --code подарунки --uk Подарунки --en Presents", ExitConflict},
+		{"types add --code Книги --uk Книги --en Books", ExitUsage},
+		{"types add --uk Книги", ExitUsage},
+		{"types rename --code подарунки --en Presents", ExitOK},
+		{"types rename --code подарунки", ExitUsage},
+		{"types rename --code книги --en Books", ExitNotFound},
+		{"types deactivate --code розваги", ExitOK},
+		{"types deactivate --code книги", ExitNotFound},
+		{"types frobnicate", ExitUsage},
+	}
+	for _, c := range cases {
+		if code, _ := run(c.args); code != c.want {
+			t.Errorf("%s: got exit code %d, want %d", c.args, code, c.want)
+		}
+	}
+
+	// orders of a deactivated type are rejected as invalid
+	args := []string{"orders", "add", "--at", "2025-12-01 09:30:00", "--type", "розваги", "--amount", "20", "--currency", "UAH", "--rate", "1"}
+	if code := Run(t.Context(), args, &bytes.Buffer{}, &bytes.Buffer{}, store, frontend.TextRenderer{}, config.Default().Reports); code != ExitUsage {
+		t.Errorf("adding an order of a deactivated type: got exit code %d, want %d", code, ExitUsage)
+	}
+
+	code, out := run("types list --format csv")
+	if code != ExitOK || strings.Contains(out, "розваги") || !strings.Contains(out, "подарунки,Подарунки,Presents,true\n") {
+		t.Errorf("list: got %d %q", code, out)
+	}
+	code, out = run("types list --all --format csv")
+	if code != ExitOK || !strings.Contains(out, "розваги,Розваги,Entertainment,false\n") {
+		t.Errorf("list --all: got %d %q", code, out)
+	}
+}
+
+func TestImportOrders(t *testing.T) {
+	ctx := t.Context()
+	store := memory.NewStore()
+	run := func(args ...string) (int, string) {
+		stdout := &bytes.Buffer{}
+		return Run(t.Context(), args, stdout, &bytes.Buffer{}, store, frontend.TextRenderer{}, config.Default().Reports), stdout.String()
+	}
+
+	file := filepath.Join(t.TempDir(), "orders.csv")
+	input := "Date;Category;Sum;Currency\n" +
+		"01.12.2025 09:30;харчування;20;UAH\n" +
+		"01.12.2025 10:00;одяг;-1;UAH\n" +
+		"01.12.2025 11:00;одяг;100;USD\n"
+	if err := os.WriteFile(file, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"orders", "import", "--delimiter", ";", "--time-format", "02.01.2006 15:04",
+		"--columns", "timestamp=Date,type=Category,amount=Sum,currency=Currency"}
+
+	code, out := run(append(append(args, "--dry-run"), file)...)
+	want := "line 3: amount: \"-1\" is not a positive number\n" +
+		"line 4: exchange rate: no official USD rate at 2025-12-01 11:00:00: exchange rate is not found\n" +
+		"would import 1 orders, 2 rows rejected\n"
+	if code != ExitOK || out != want {
+		t.Errorf("dry run: got %d %q, want %q", code, out, want)
+	}
+	if orders, _ := store.SelectAllOrders(ctx, 0); len(orders) != 0 {
+		t.Errorf("dry run imported %v", orders)
+	}
+
+	code, out = run(append(args, file)...)
+	if code != ExitOK || !strings.HasSuffix(out, "imported 1 orders, 2 rows rejected\n") {
+		t.Errorf("import: got %d %q", code, out)
+	}
+	if orders, _ := store.SelectAllOrders(ctx, 0); len(orders) != 1 || orders[0].Type != "харчування" {
+		t.Errorf("got %v", orders)
+	}
+
+	if code, _ = run("orders", "import", "--dry-run"); code != ExitUsage {
+		t.Errorf("missing file: got exit code %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestExport(t *testing.T) {
+	store := memory.NewStore()
+	run := func(args ...string) (int, string) {
+		stdout := &bytes.Buffer{}
+		return Run(t.Context(), args, stdout, &bytes.Buffer{}, store, frontend.TextRenderer{}, config.Default().Reports), stdout.String()
+	}
+
+	if code, _ := run("orders", "add", "--at", "2025-12-01 09:30:00", "--type", "харчування",
+		"--amount", "20", "--currency", "UAH", "--rate", "1"); code != ExitOK {
+		t.Fatalf("add: got exit code %d", code)
+	}
+
+	code, out := run("export", "orders")
+	want := "id,timestamp,type,amount,currency,exchangeRate,amountUah\n" +
+		"1,2025-12-01T09:30:00Z,харчування,20.00,UAH,1.000000,20.00\n"
+	if code != ExitOK || out != want {
+		t.Errorf("csv: got %d %q, want %q", code, out, want)
+	}
+
+	// export takes the parameters of the reports
+	for _, c := range []struct{ report, export []string }{
+		{[]string{"report", "small-orders", "--type", "одяг", "--threshold", "30"}, []string{"small-orders", "--type", "одяг", "--threshold", "30"}},
+		{[]string{"report", "periods", "--bucket-minutes", "720", "--big-sale", "10"}, []string{"periods", "--bucket-minutes", "720", "--big-sale", "10"}},
+		{[]string{"report", "revenue", "--by", "day"}, []string{"revenue", "--by", "day"}},
+		{[]string{"report", "biggest-dates", "--limit", "1"}, []string{"biggest-dates", "--biggest-limit", "1"}},
+	} {
+		_, want := run(append(c.report, "--format", "csv")...)
+		// flags go before the table names
+		args := append([]string{"export"}, c.export[1:]...)
+		code, out = run(append(args, c.export[0])...)
+		// small-orders prints its months too, which are another export
+		if code != ExitOK || out == "" || !strings.HasPrefix(want, out) {
+			t.Errorf("%v: got %d %q, want the start of %q", c.export, code, out, want)
+		}
+	}
+
+	file := filepath.Join(t.TempDir(), "reports.xlsx")
+	if code, _ = run("export", "--format", "xlsx", "--output", file, "all"); code != ExitOK {
+		t.Errorf("xlsx: got exit code %d", code)
+	}
+	if info, err := os.Stat(file); err != nil || info.Size() == 0 {
+		t.Errorf("xlsx: got %v %v", info, err)
+	}
+
+	for _, args := range [][]string{
+		{"export", "all"},
+		{"export", "--format", "pdf", "orders"},
+		{"export", "--format", "json", "customers"},
+		{"export", "--bucket-minutes", "0", "periods"},
+		{"export"},
+	} {
+		if code, _ = run(args...); code != ExitUsage {
+			t.Errorf("%v: got exit code %d, want %d", args, code, ExitUsage)
+		}
+	}
+}
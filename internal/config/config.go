@@ -0,0 +1,344 @@
+// Package config holds the settings shared by the app, migration and data
+// generation binaries.
+package config
+
+import (
+	"bytes"
+	"coursework/internal/frontend"
+	"coursework/internal/models"
+	"coursework/internal/postgres"
+	"coursework/internal/validation"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is read when no file is named and it exists.
+const DefaultFile = "config.yaml"
+
+type Config struct {
+	// DatabaseURL is left empty to connect with the PG* environment
+	// variables, e.g. PGHOST and PGPASSWORD.
+	DatabaseURL string `yaml:"database_url"`
+	// ConnectTimeout limits every attempt to reach the database and
+	// ConnectRetries is the number of attempts after the first one.
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+	ConnectRetries int           `yaml:"connect_retries"`
+	// QueryTimeout limits every query and ReportTimeout every report, import
+	// and purge.
+	QueryTimeout  time.Duration `yaml:"query_timeout"`
+	ReportTimeout time.Duration `yaml:"report_timeout"`
+	LogFile       string        `yaml:"log_file"`
+	// TimeZone is the business time zone, Location holds it once loaded.
+	TimeZone string         `yaml:"time_zone"`
+	Location *time.Location `yaml:"-"`
+	// Format is the output format of the menu.
+	Format string `yaml:"format"`
+	// Memory keeps orders in memory instead of Postgres.
+	Memory  bool    `yaml:"memory"`
+	Reports Reports `yaml:"reports"`
+	// Validation are the rules new and changed orders must follow.
+	Validation validation.Rules `yaml:"validation"`
+}
+
+// Reports are the parameters the menu offers before any are entered, and the
+// defaults of the report commands and API endpoints.
+type Reports struct {
+	BiggestLimit  int                `yaml:"biggest_limit"`
+	SmallestLimit int                `yaml:"smallest_limit"`
+	OrderType     string             `yaml:"order_type"`
+	LessThan      decimal.Decimal    `yaml:"less_than"`
+	BucketMinutes int                `yaml:"bucket_minutes"`
+	BigSale       decimal.Decimal    `yaml:"big_sale"`
+	Granularity   models.Granularity `yaml:"granularity"`
+}
+
+func Default() Config {
+	return Config{
+		DatabaseURL:    "",
+		ConnectTimeout: postgres.DefaultConnectOptions.Timeout,
+		ConnectRetries: postgres.DefaultConnectOptions.Retries,
+		QueryTimeout:   postgres.DefaultTimeouts.Query,
+		ReportTimeout:  postgres.DefaultTimeouts.Report,
+		LogFile:        "app.log",
+		TimeZone:       "Europe/Kyiv",
+		Format:         "text",
+		Reports: Reports{
+			BiggestLimit:  5,
+			SmallestLimit: 6,
+			OrderType:     "харчування",
+			LessThan:      decimal.NewFromInt(50),
+			BucketMinutes: models.DefaultBuckets.Minutes,
+			BigSale:       models.DefaultBuckets.BigSale,
+			Granularity:   models.ByMonth,
+		},
+		Validation: validation.DefaultRules(),
+	}
+}
+
+// setting is one value that can be set by a flag and an environment
+// variable.
+type setting struct {
+	flag, env, usage string
+	boolean          bool
+	set              func(c *Config, raw string) error
+}
+
+var settings = []setting{
+	{flag: "db-url", env: "DB_URL", usage: "Postgres connection URL", set: func(c *Config, raw string) error {
+		c.DatabaseURL = raw
+		return nil
+	}},
+	{flag: "connect-timeout", env: "DB_CONNECT_TIMEOUT", usage: "time to wait for the database on every attempt", set: func(c *Config, raw string) (err error) {
+		c.ConnectTimeout, err = time.ParseDuration(raw)
+		return err
+	}},
+	{flag: "connect-retries", env: "DB_CONNECT_RETRIES", usage: "attempts to reach the database after the first one", set: func(c *Config, raw string) (err error) {
+		c.ConnectRetries, err = strconv.Atoi(raw)
+		return err
+	}},
+	{flag: "query-timeout", env: "DB_QUERY_TIMEOUT", usage: "time a query may take", set: func(c *Config, raw string) (err error) {
+		c.QueryTimeout, err = time.ParseDuration(raw)
+		return err
+	}},
+	{flag: "report-timeout", env: "DB_REPORT_TIMEOUT", usage: "time a report, import or purge may take", set: func(c *Config, raw string) (err error) {
+		c.ReportTimeout, err = time.ParseDuration(raw)
+		return err
+	}},
+	{flag: "log-file", env: "LOG_FILE", usage: "file the log is appended to", set: func(c *Config, raw string) error {
+		c.LogFile = raw
+		return nil
+	}},
+	{flag: "tz", env: "TIME_ZONE", usage: "business time zone order times are entered, shown and grouped in", set: func(c *Config, raw string) error {
+		c.TimeZone = raw
+		return nil
+	}},
+	{flag: "format", env: "OUTPUT_FORMAT", usage: "output format of the menu", set: func(c *Config, raw string) error {
+		c.Format = raw
+		return nil
+	}},
+	{flag: "memory", env: "MEMORY_STORE", usage: "keep orders in memory instead of Postgres", boolean: true, set: func(c *Config, raw string) (err error) {
+		c.Memory, err = strconv.ParseBool(raw)
+		return err
+	}},
+	{flag: "currencies", env: "ORDER_CURRENCIES", usage: "comma-separated currency codes orders may use", set: func(c *Config, raw string) error {
+		c.Validation.Currencies = splitList(raw)
+		return nil
+	}},
+	{flag: "min-amount", env: "ORDER_MIN_AMOUNT", usage: "smallest amount of an order", set: func(c *Config, raw string) (err error) {
+		c.Validation.MinAmount, err = decimal.NewFromString(raw)
+		return err
+	}},
+	{flag: "max-amount", env: "ORDER_MAX_AMOUNT", usage: "largest amount of an order", set: func(c *Config, raw string) (err error) {
+		c.Validation.MaxAmount, err = decimal.NewFromString(raw)
+		return err
+	}},
+	{flag: "biggest-limit", env: "REPORT_BIGGEST_LIMIT", usage: "dates listed by the biggest orders report", set: func(c *Config, raw string) (err error) {
+		c.Reports.BiggestLimit, err = strconv.Atoi(raw)
+		return err
+	}},
+	{flag: "smallest-limit", env: "REPORT_SMALLEST_LIMIT", usage: "orders the smallest types report looks at", set: func(c *Config, raw string) (err error) {
+		c.Reports.SmallestLimit, err = strconv.Atoi(raw)
+		return err
+	}},
+	{flag: "order-type", env: "REPORT_ORDER_TYPE", usage: "order type of the small orders report", set: func(c *Config, raw string) error {
+		c.Reports.OrderType = raw
+		return nil
+	}},
+	{flag: "less-than", env: "REPORT_LESS_THAN", usage: "threshold in UAH of the small orders report", set: func(c *Config, raw string) (err error) {
+		c.Reports.LessThan, err = decimal.NewFromString(raw)
+		return err
+	}},
+	{flag: "bucket-minutes", env: "REPORT_BUCKET_MINUTES", usage: "width in minutes of the periods of the day", set: func(c *Config, raw string) (err error) {
+		c.Reports.BucketMinutes, err = strconv.Atoi(raw)
+		return err
+	}},
+	{flag: "big-sale", env: "REPORT_BIG_SALE", usage: "amount in UAH above which an order is a big sale", set: func(c *Config, raw string) (err error) {
+		c.Reports.BigSale, err = decimal.NewFromString(raw)
+		return err
+	}},
+	{flag: "granularity", env: "REPORT_GRANULARITY", usage: "period of the revenue report: day, week or month", set: func(c *Config, raw string) error {
+		c.Reports.Granularity = models.Granularity(raw)
+		return nil
+	}},
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Load defines the settings as flags on flags, parses args with it and builds
+// the configuration from, in increasing precedence, the defaults, the YAML
+// file named by -config or CONFIG_FILE, the environment, which a .env file
+// may add to, and the flags. The configuration is validated.
+func Load(flags *flag.FlagSet, args []string) (Config, error) {
+	_ = godotenv.Load()
+	return load(flags, args, os.LookupEnv)
+}
+
+func load(flags *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	file := flags.String("config", "", "YAML configuration file, "+DefaultFile+" if it exists")
+
+	type flagValue struct {
+		setting setting
+		raw     string
+	}
+	var set []flagValue
+	for _, s := range settings {
+		record := func(raw string) error {
+			set = append(set, flagValue{s, raw})
+			return nil
+		}
+		if s.boolean {
+			flags.BoolFunc(s.flag, s.usage, record)
+		} else {
+			flags.Func(s.flag, s.usage, record)
+		}
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	c := Default()
+
+	name, required := *file, true
+	if name == "" {
+		name, required = lookupEnv("CONFIG_FILE")
+	}
+	if name == "" {
+		name = DefaultFile
+	}
+	if err := c.readFile(name, required); err != nil {
+		return Config{}, err
+	}
+
+	for _, s := range settings {
+		if raw, ok := lookupEnv(s.env); ok {
+			if err := s.set(&c, raw); err != nil {
+				return Config{}, fmt.Errorf("error reading config: %s: %w", s.env, err)
+			}
+		}
+	}
+
+	for _, f := range set {
+		if err := f.setting.set(&c, f.raw); err != nil {
+			return Config{}, fmt.Errorf("error reading config: -%s: %w", f.setting.flag, err)
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return c, nil
+}
+
+// readFile overrides the settings present in the file. A file that isn't
+// required may be missing, unknown keys are rejected.
+func (c *Config) readFile(name string, required bool) error {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading config: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("error reading config %s: %w", name, err)
+	}
+	return nil
+}
+
+// Validate checks every setting, reporting all the invalid ones, and loads
+// Location.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.ConnectTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("connect_timeout: must be positive, got %s", c.ConnectTimeout))
+	}
+	if c.ConnectRetries < 0 {
+		errs = append(errs, fmt.Errorf("connect_retries: must not be negative, got %d", c.ConnectRetries))
+	}
+	if c.QueryTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("query_timeout: must be positive, got %s", c.QueryTimeout))
+	}
+	if c.ReportTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("report_timeout: must be positive, got %s", c.ReportTimeout))
+	}
+	if c.LogFile == "" {
+		errs = append(errs, errors.New("log_file: must be set"))
+	}
+
+	location, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("time_zone: %w", err))
+	}
+	c.Location = location
+
+	if _, err = frontend.NewRenderer(c.Format); err != nil {
+		errs = append(errs, fmt.Errorf("format: %w", err))
+	}
+
+	r := c.Reports
+	if r.BiggestLimit < 0 {
+		errs = append(errs, fmt.Errorf("reports.biggest_limit: must not be negative, got %d", r.BiggestLimit))
+	}
+	if r.SmallestLimit < 0 {
+		errs = append(errs, fmt.Errorf("reports.smallest_limit: must not be negative, got %d", r.SmallestLimit))
+	}
+	if err = r.Buckets().Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("reports: %w", err))
+	}
+	if c.Reports.Granularity, err = models.ParseGranularity(string(r.Granularity)); err != nil {
+		errs = append(errs, fmt.Errorf("reports.granularity: %w", err))
+	}
+
+	if err = c.Validation.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("validation: %w", err))
+	}
+
+	if err = errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+// ConnectOptions are the options of postgres.Connect.
+func (c Config) ConnectOptions() postgres.ConnectOptions {
+	opts := postgres.DefaultConnectOptions
+	opts.Timeout = c.ConnectTimeout
+	opts.Retries = c.ConnectRetries
+	return opts
+}
+
+// Timeouts are the timeouts of postgres.DbController.
+func (c Config) Timeouts() postgres.Timeouts {
+	return postgres.Timeouts{Query: c.QueryTimeout, Report: c.ReportTimeout}
+}
+
+// Buckets are the periods of the day the reports start with.
+func (r Reports) Buckets() models.Buckets {
+	return models.Buckets{Minutes: r.BucketMinutes, BigSale: r.BigSale}
+}
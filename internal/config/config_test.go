@@ -0,0 +1,154 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func loadWith(args []string, env map[string]string) (Config, error) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return load(flags, args, func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	})
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+
+		got, err := loadWith(nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.TimeZone != "Europe/Kyiv" || got.Location.String() != "Europe/Kyiv" {
+			t.Errorf("got %s, want Europe/Kyiv", got.TimeZone)
+		}
+		if got.Reports.BiggestLimit != 5 || !got.Reports.LessThan.Equal(decimal.NewFromInt(50)) {
+			t.Errorf("got %+v, want the default reports", got.Reports)
+		}
+		if got.DatabaseURL != "" {
+			t.Errorf("got %s, want no database url so the PG* variables apply", got.DatabaseURL)
+		}
+	})
+
+	t.Run("file, environment and flags in increasing precedence", func(t *testing.T) {
+		name := writeFile(t, `
+database_url: postgres://file
+log_file: file.log
+time_zone: UTC
+reports:
+  biggest_limit: 10
+  less_than: "12.50"
+  granularity: week
+`)
+		env := map[string]string{"CONFIG_FILE": name, "LOG_FILE": "env.log", "TIME_ZONE": "Europe/Warsaw"}
+
+		got, err := loadWith([]string{"-tz", "America/New_York", "report", "types"}, env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.DatabaseURL != "postgres://file" {
+			t.Errorf("got %s, want the file's database url", got.DatabaseURL)
+		}
+		if got.LogFile != "env.log" {
+			t.Errorf("got %s, want env.log", got.LogFile)
+		}
+		if got.Location.String() != "America/New_York" {
+			t.Errorf("got %s, want America/New_York", got.Location)
+		}
+		if got.Reports.BiggestLimit != 10 || !got.Reports.LessThan.Equal(decimal.RequireFromString("12.5")) ||
+			got.Reports.Granularity != "week" || got.Reports.SmallestLimit != 6 {
+			t.Errorf("got %+v", got.Reports)
+		}
+	})
+
+	t.Run("validation rules", func(t *testing.T) {
+		name := writeFile(t, `
+validation:
+  currencies: [UAH, USD]
+  rates:
+    USD: {min: "30", max: "50"}
+`)
+		env := map[string]string{"CONFIG_FILE": name, "ORDER_CURRENCIES": "UAH, USD, EUR,"}
+
+		got, err := loadWith([]string{"-max-amount", "5000"}, env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := got.Validation
+		if len(v.Currencies) != 3 || v.Currencies[2] != "EUR" {
+			t.Errorf("got currencies %v, want the environment's", v.Currencies)
+		}
+		if !v.MaxAmount.Equal(decimal.NewFromInt(5000)) {
+			t.Errorf("got max amount %s, want 5000", v.MaxAmount)
+		}
+		if usd, uah := v.Rates["USD"], v.Rates["UAH"]; !usd.Min.Equal(decimal.NewFromInt(30)) || !uah.Max.Equal(decimal.NewFromInt(1)) {
+			t.Errorf("got rates %v, want the USD band and the default UAH one", v.Rates)
+		}
+	})
+
+	t.Run("flag names the file", func(t *testing.T) {
+		name := writeFile(t, "memory: true\n")
+
+		got, err := loadWith([]string{"-config", name}, map[string]string{"CONFIG_FILE": "missing.yaml"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Memory {
+			t.Error("got memory off, want it on")
+		}
+	})
+
+	t.Run("named file must exist", func(t *testing.T) {
+		if _, err := loadWith([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, nil); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("unknown keys are rejected", func(t *testing.T) {
+		name := writeFile(t, "time_zon: UTC\n")
+
+		if _, err := loadWith([]string{"-config", name}, nil); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("every invalid setting is reported", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+
+		_, err := loadWith([]string{"-tz", "Mars/Olympus", "-format", "pdf", "-bucket-minutes", "0"}, nil)
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		for _, want := range []string{"time_zone", "format", "bucket minutes"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("got %v, want it to mention %s", err, want)
+			}
+		}
+	})
+
+	t.Run("malformed environment value", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+
+		if _, err := loadWith(nil, map[string]string{"REPORT_BIGGEST_LIMIT": "many"}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package export
+
+import (
+	"coursework/internal/models"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Kind tells the writers how to encode the cells of a column.
+type Kind int
+
+const (
+	Text Kind = iota
+	Integer
+	// Number cells hold decimal.Decimal values.
+	Number
+	// Float cells hold float64 values.
+	Float
+	// Timestamp cells hold time.Time values.
+	Timestamp
+	// Date cells hold time.Time values of which only the date is written.
+	Date
+)
+
+type Column struct {
+	Name string
+	Kind Kind
+	// Places is the number of decimal places of Number and Float cells.
+	Places int32
+}
+
+// Table is the result of a query or a report in a form every writer accepts.
+// Name becomes the sheet name in XLSX and the key in multi-table JSON. A nil
+// cell has no value and is written empty, or as null in JSON.
+type Table struct {
+	Name    string
+	Columns []Column
+	Rows    [][]any
+}
+
+var orderColumns = []Column{
+	{Name: "id", Kind: Integer},
+	{Name: "timestamp", Kind: Timestamp},
+	{Name: "type", Kind: Text},
+	{Name: "amount", Kind: Number, Places: 2},
+	{Name: "currency", Kind: Text},
+	{Name: "exchangeRate", Kind: Number, Places: 6},
+	{Name: "amountUah", Kind: Number, Places: 2},
+}
+
+func Orders(name string, orders []models.Order) Table {
+	table := Table{Name: name, Columns: orderColumns}
+	for _, o := range orders {
+		table.Rows = append(table.Rows, []any{o.Id, o.TimeStamp, o.Type, o.Amount, o.Currency, o.ExchangeRate, o.AmountUah()})
+	}
+	return table
+}
+
+func BiggestOrders(orders []models.BiggestOrders) Table {
+	table := Table{
+		Name: "biggest-dates",
+		Columns: []Column{
+			{Name: "date", Kind: Date},
+			{Name: "totalUah", Kind: Number, Places: 2},
+		},
+	}
+	for _, o := range orders {
+		table.Rows = append(table.Rows, []any{o.Date, o.TotalUah})
+	}
+	return table
+}
+
+func PeriodStats(stats []models.PeriodStats) Table {
+	table := Table{
+		Name: "periods",
+		Columns: []Column{
+			{Name: "timePeriod", Kind: Text},
+			{Name: "start", Kind: Text},
+			{Name: "end", Kind: Text},
+			{Name: "totalSales", Kind: Integer},
+			{Name: "bigSales", Kind: Integer},
+			{Name: "smallSales", Kind: Integer},
+		},
+	}
+	for _, s := range stats {
+		table.Rows = append(table.Rows, []any{s.TimePeriod, s.Start, s.End, s.TotalSales, s.BigSales, s.SmallSales})
+	}
+	return table
+}
+
+func Revenue(points []models.RevenuePoint) Table {
+	table := Table{
+		Name: "revenue",
+		Columns: []Column{
+			{Name: "period", Kind: Date},
+			{Name: "orders", Kind: Integer},
+			{Name: "totalUah", Kind: Number, Places: 2},
+			{Name: "avgUah", Kind: Number, Places: 2},
+			{Name: "change", Kind: Number, Places: 2},
+			{Name: "changePercent", Kind: Number, Places: 2},
+		},
+	}
+	for _, p := range points {
+		var changePercent any
+		if p.ChangePercent != nil {
+			changePercent = *p.ChangePercent
+		}
+		table.Rows = append(table.Rows, []any{p.Period, p.Orders, p.TotalUah, p.AvgUah, p.Change, changePercent})
+	}
+	return table
+}
+
+// Breakdown writes a row per order type, with the orders, the amount and
+// the amount in UAH of every currency followed by the type's totals. The
+// last row holds the totals of the currencies.
+func Breakdown(b models.Breakdown) Table {
+	table := Table{Name: "breakdown", Columns: []Column{{Name: "type", Kind: Text}}}
+	for _, currency := range b.Currencies {
+		table.Columns = append(table.Columns,
+			Column{Name: currency + " orders", Kind: Integer},
+			Column{Name: currency + " amount", Kind: Number, Places: 2},
+			Column{Name: currency + " amountUah", Kind: Number, Places: 2})
+	}
+	table.Columns = append(table.Columns,
+		Column{Name: "orders", Kind: Integer},
+		Column{Name: "amountUah", Kind: Number, Places: 2},
+		Column{Name: "share", Kind: Number, Places: 2})
+
+	for _, r := range b.Rows {
+		cells := []any{r.Type}
+		for _, cell := range r.Cells {
+			cells = append(cells, cell.Orders, cell.Amount, cell.AmountUah)
+		}
+		table.Rows = append(table.Rows, append(cells, r.Total.Orders, r.Total.AmountUah, r.Share))
+	}
+
+	total := []any{"total"}
+	for _, cell := range b.Totals {
+		total = append(total, cell.Orders, cell.Amount, cell.AmountUah)
+	}
+	share := decimal.Zero
+	if b.Total.Orders > 0 {
+		share = decimal.NewFromInt(100)
+	}
+	table.Rows = append(table.Rows, append(total, b.Total.Orders, b.Total.AmountUah, share))
+
+	return table
+}
+
+func SmallestTypes(orderTypes []string) Table {
+	table := Table{Name: "smallest-types", Columns: []Column{{Name: "type", Kind: Text}}}
+	for _, orderType := range orderTypes {
+		table.Rows = append(table.Rows, []any{orderType})
+	}
+	return table
+}
+
+func OrderTypes(types []models.OrderType) Table {
+	table := Table{
+		Name: "order-types",
+		Columns: []Column{
+			{Name: "code", Kind: Text},
+			{Name: "nameUk", Kind: Text},
+			{Name: "nameEn", Kind: Text},
+			{Name: "active", Kind: Text},
+		},
+	}
+	for _, t := range types {
+		table.Rows = append(table.Rows, []any{t.Code, t.NameUk, t.NameEn, t.Active})
+	}
+	return table
+}
+
+// SmallOrders holds the statistics of a MonthlyDistribution together with
+// its arguments; MonthlyCounts holds its months.
+func SmallOrders(d models.MonthlyDistribution) Table {
+	return Table{
+		Name: "small-orders",
+		Columns: []Column{
+			{Name: "type", Kind: Text},
+			{Name: "threshold", Kind: Number, Places: 2},
+			{Name: "months", Kind: Integer},
+			{Name: "mean", Kind: Float, Places: 2},
+			{Name: "median", Kind: Float, Places: 2},
+			{Name: "stdDev", Kind: Float, Places: 2},
+		},
+		Rows: [][]any{{d.Type, d.Threshold, len(d.Months), d.Mean, d.Median, d.StdDev}},
+	}
+}
+
+func MonthlyCounts(d models.MonthlyDistribution) Table {
+	table := Table{
+		Name: "small-orders-by-month",
+		Columns: []Column{
+			{Name: "month", Kind: Text},
+			{Name: "orders", Kind: Integer},
+		},
+	}
+	for _, m := range d.Months {
+		table.Rows = append(table.Rows, []any{m.Month.Format("2006-01"), m.Orders})
+	}
+	return table
+}
+
+func timeOf(cell any) time.Time {
+	t, _ := cell.(time.Time)
+	return t
+}
+
+func decimalOf(cell any) decimal.Decimal {
+	d, _ := cell.(decimal.Decimal)
+	return d
+}
@@ -0,0 +1,123 @@
+package export
+
+import (
+	"bytes"
+	"coursework/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+)
+
+func testTables() []Table {
+	orders := []models.Order{{
+		Id:           1,
+		TimeStamp:    time.Date(2025, 12, 1, 9, 30, 0, 0, time.UTC),
+		Type:         "харчування, кава",
+		Amount:       decimal.RequireFromString("20.5"),
+		Currency:     "USD",
+		ExchangeRate: decimal.RequireFromString("41.7"),
+	}}
+	biggest := []models.BiggestOrders{{
+		Date:     time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+		TotalUah: decimal.RequireFromString("854.85"),
+	}}
+
+	return []Table{Orders("orders", orders), BiggestOrders(biggest), SmallOrders(models.MonthlyDistribution{Type: "харчування", Threshold: decimal.NewFromInt(50), Mean: 1.5})}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, testTables()[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "id,timestamp,type,amount,currency,exchangeRate,amountUah\n" +
+		"1,2025-12-01T09:30:00Z,\"харчування, кава\",20.50,USD,41.700000,854.85\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	t.Run("one table", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteJSON(&buf, testTables()[1]); err != nil {
+			t.Fatal(err)
+		}
+
+		want := "[\n  {\n    \"date\": \"2025-12-01\",\n    \"totalUah\": 854.85\n  }\n]\n"
+		if got := buf.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("several tables", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteJSON(&buf, testTables()...); err != nil {
+			t.Fatal(err)
+		}
+
+		got := buf.String()
+		for _, want := range []string{`"orders": [`, `"amount": 20.50,`, `"timestamp": "2025-12-01T09:30:00Z"`, `"mean": 1.50`} {
+			if !strings.Contains(got, want) {
+				t.Errorf("got %s, want it to contain %s", got, want)
+			}
+		}
+	})
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, testTables()...); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if got, want := strings.Join(f.GetSheetList(), ","), "orders,biggest-dates,small-orders"; got != want {
+		t.Errorf("got sheets %s, want %s", got, want)
+	}
+
+	cases := []struct {
+		sheet, cell string
+		cellType    excelize.CellType
+		value       string
+	}{
+		{"orders", "C1", excelize.CellTypeSharedString, "type"},
+		{"orders", "B2", excelize.CellTypeUnset, "2025-12-01 09:30:00"},
+		{"orders", "D2", excelize.CellTypeUnset, "20.50"},
+		{"orders", "F2", excelize.CellTypeUnset, "41.700000"},
+		{"biggest-dates", "A2", excelize.CellTypeUnset, "2025-12-01"},
+		{"small-orders", "D2", excelize.CellTypeUnset, "1.50"},
+	}
+	for _, c := range cases {
+		cellType, err := f.GetCellType(c.sheet, c.cell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, err := f.GetCellValue(c.sheet, c.cell)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cellType != c.cellType || value != c.value {
+			t.Errorf("%s!%s: got %v %q, want %v %q", c.sheet, c.cell, cellType, value, c.cellType, c.value)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("xlsx"); err != nil || f != XLSX {
+		t.Errorf("got %v %v", f, err)
+	}
+	if _, err := ParseFormat("xls"); err == nil {
+		t.Error("expected an error")
+	}
+}
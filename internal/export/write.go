@@ -0,0 +1,180 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Format is an output format accepted by Write.
+type Format string
+
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+	XLSX Format = "xlsx"
+)
+
+func ParseFormat(raw string) (Format, error) {
+	switch f := Format(raw); f {
+	case CSV, JSON, XLSX:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown export format %q, expected csv, json or xlsx", raw)
+}
+
+// Write encodes the tables in the given format. CSV holds a single table.
+func Write(w io.Writer, format Format, tables ...Table) error {
+	switch format {
+	case CSV:
+		if len(tables) != 1 {
+			return errors.New("csv export holds exactly one table")
+		}
+		return WriteCSV(w, tables[0])
+	case JSON:
+		return WriteJSON(w, tables...)
+	case XLSX:
+		return WriteXLSX(w, tables...)
+	}
+	return fmt.Errorf("unknown export format %q", format)
+}
+
+// WriteCSV writes the table with a header row. Timestamps are in RFC 3339 and
+// numbers use a decimal point.
+func WriteCSV(w io.Writer, table Table) error {
+	writer := csv.NewWriter(w)
+
+	header := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		header[i] = column.Name
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("couldn't write csv: %w", err)
+	}
+
+	record := make([]string, len(table.Columns))
+	for _, row := range table.Rows {
+		for i, column := range table.Columns {
+			record[i] = FormatCell(column, row[i])
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("couldn't write csv: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("couldn't write csv: %w", err)
+	}
+
+	return nil
+}
+
+// WriteJSON writes a single table as an array of objects keyed by column
+// name, and several tables as an object keyed by table name. Numbers are JSON
+// numbers, never strings.
+func WriteJSON(w io.Writer, tables ...Table) error {
+	var buf bytes.Buffer
+
+	if len(tables) == 1 {
+		if err := appendRows(&buf, tables[0]); err != nil {
+			return fmt.Errorf("couldn't write json: %w", err)
+		}
+	} else {
+		buf.WriteByte('{')
+		for i, table := range tables {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			appendKey(&buf, table.Name)
+			if err := appendRows(&buf, table); err != nil {
+				return fmt.Errorf("couldn't write json: %w", err)
+			}
+		}
+		buf.WriteByte('}')
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+		return fmt.Errorf("couldn't write json: %w", err)
+	}
+	indented.WriteByte('\n')
+
+	if _, err := indented.WriteTo(w); err != nil {
+		return fmt.Errorf("couldn't write json: %w", err)
+	}
+
+	return nil
+}
+
+// appendRows encodes the rows by hand to keep the keys in column order.
+func appendRows(buf *bytes.Buffer, table Table) error {
+	buf.WriteByte('[')
+	for i, row := range table.Rows {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		buf.WriteByte('{')
+		for j, column := range table.Columns {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			appendKey(buf, column.Name)
+
+			value, err := json.Marshal(jsonCell(column, row[j]))
+			if err != nil {
+				return err
+			}
+			buf.Write(value)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+
+	return nil
+}
+
+func appendKey(buf *bytes.Buffer, key string) {
+	encoded, _ := json.Marshal(key)
+	buf.Write(encoded)
+	buf.WriteByte(':')
+}
+
+func jsonCell(column Column, cell any) any {
+	if cell == nil {
+		return nil
+	}
+	switch column.Kind {
+	case Number, Float:
+		return json.Number(FormatCell(column, cell))
+	case Timestamp, Date:
+		return FormatCell(column, cell)
+	}
+	return cell
+}
+
+// FormatCell writes a cell as text the way CSV does: timestamps in RFC 3339
+// and numbers with the column's decimal places.
+func FormatCell(column Column, cell any) string {
+	if cell == nil {
+		return ""
+	}
+	switch column.Kind {
+	case Number:
+		return decimalOf(cell).StringFixed(column.Places)
+	case Float:
+		f, _ := cell.(float64)
+		return strconv.FormatFloat(f, 'f', int(column.Places), 64)
+	case Timestamp:
+		return timeOf(cell).Format(time.RFC3339)
+	case Date:
+		return timeOf(cell).Format(time.DateOnly)
+	}
+	return fmt.Sprint(cell)
+}
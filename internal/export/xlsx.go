@@ -0,0 +1,124 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// WriteXLSX writes a workbook with one sheet per table. Numbers and dates are
+// stored as typed cells, formatted with the column's decimal places.
+func WriteXLSX(w io.Writer, tables ...Table) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	header, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return fmt.Errorf("couldn't write xlsx: %w", err)
+	}
+
+	for i, table := range tables {
+		sheet := table.Name
+		if i == 0 {
+			err = f.SetSheetName(f.GetSheetName(0), sheet)
+		} else {
+			_, err = f.NewSheet(sheet)
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't write xlsx: sheet %q: %w", sheet, err)
+		}
+
+		if err = writeSheet(f, sheet, table, header); err != nil {
+			return fmt.Errorf("couldn't write xlsx: sheet %q: %w", sheet, err)
+		}
+	}
+
+	if err = f.Write(w); err != nil {
+		return fmt.Errorf("couldn't write xlsx: %w", err)
+	}
+
+	return nil
+}
+
+func writeSheet(f *excelize.File, sheet string, table Table, header int) error {
+	for col, column := range table.Columns {
+		name, err := excelize.ColumnNumberToName(col + 1)
+		if err != nil {
+			return err
+		}
+
+		if err = f.SetCellStr(sheet, name+"1", column.Name); err != nil {
+			return err
+		}
+		if err = f.SetCellStyle(sheet, name+"1", name+"1", header); err != nil {
+			return err
+		}
+
+		for row, cells := range table.Rows {
+			if err = f.SetCellValue(sheet, fmt.Sprintf("%s%d", name, row+2), xlsxCell(column, cells[col])); err != nil {
+				return err
+			}
+		}
+
+		if err = setColumnFormat(f, sheet, name, column, len(table.Rows)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setColumnFormat(f *excelize.File, sheet, name string, column Column, rows int) error {
+	var numFmt string
+	width := 12.0
+
+	switch column.Kind {
+	case Number, Float:
+		numFmt = "0"
+		if column.Places > 0 {
+			numFmt += "." + strings.Repeat("0", int(column.Places))
+		}
+	case Timestamp:
+		numFmt, width = "yyyy-mm-dd hh:mm:ss", 20
+	case Date:
+		numFmt = "yyyy-mm-dd"
+	case Text:
+		width = 20
+	}
+
+	if err := f.SetColWidth(sheet, name, name, width); err != nil {
+		return err
+	}
+	if numFmt == "" || rows == 0 {
+		return nil
+	}
+
+	style, err := f.NewStyle(&excelize.Style{CustomNumFmt: &numFmt})
+	if err != nil {
+		return err
+	}
+
+	return f.SetCellStyle(sheet, name+"2", fmt.Sprintf("%s%d", name, rows+1), style)
+}
+
+func xlsxCell(column Column, cell any) any {
+	if cell == nil {
+		return nil
+	}
+	switch column.Kind {
+	case Number:
+		return decimalOf(cell).InexactFloat64()
+	case Timestamp, Date:
+		return wallClock(timeOf(cell))
+	}
+	return cell
+}
+
+// wallClock moves t to UTC keeping its clock reading, since Excel has no
+// time zones and would otherwise show the time in UTC.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
@@ -1,25 +1,34 @@
 package frontend
 
 import (
+	"bufio"
+	"coursework/internal/export"
 	"coursework/internal/models"
 	"fmt"
 	"io"
+	"strings"
 )
 
 const (
-	listAllOrders          = "1. List all orders"
-	addNewOrder            = "2. Add new order"
-	updateOrder            = "3. Update order type"
-	deleteOrderString      = "4. Delete order"
-	biggestOrdersDates     = "5. Show 5 dates with biggest orders"
-	ordersWhenRateChanged  = "6. Show orders at days when exchange rate changed"
-	avgNumOrdersLessThen50 = "7. Show avg number of orders of type food less than 50 UAH per months"
-	typesOfSmallestOrders  = "8. Show types of 6 smallest orders"
-	statsFor8HrPeriods     = "9. Show stats for 8 hours periods"
-	exitProgram            = "10. Exit program"
-
-	PrintLimit    = "How many items to print? (0 will print all items)"
-	InvalidChoice = "Invalid choice"
+	listAllOrders         = "1. List orders"
+	addNewOrder           = "2. Add new order"
+	updateOrder           = "3. Edit order"
+	deleteOrderString     = "4. Delete order"
+	biggestOrdersDates    = "5. Show dates with biggest orders"
+	ordersWhenRateChanged = "6. Show orders at days when exchange rate changed"
+	avgNumOrdersLessThan  = "7. Show monthly distribution of small orders of a type"
+	typesOfSmallestOrders = "8. Show types of smallest orders"
+	statsForPeriods       = "9. Show stats for periods of the day"
+	revenueOverTime       = "10. Show revenue over time"
+	breakdown             = "11. Show breakdown by type and currency"
+	manageOrderTypes      = "12. Manage order types"
+	exitProgram           = "13. Exit program"
+
+	PrintLimit     = "How many orders per page? (0 or Enter prints all)"
+	PageNavigation = "\n[n]ext page, [p]revious page, [q]uit: "
+	NoMorePages    = "No more pages in that direction"
+	InvalidChoice  = "Invalid choice"
+	TypeActions    = "\n[a]dd, [r]ename, [d]eactivate, [q]uit: "
 
 	TimeFormat = "2006-01-02 15:04:05"
 )
@@ -31,43 +40,88 @@ func PrintOptions(writer io.Writer) {
 	fmt.Fprintf(writer, deleteOrderString+"\n")
 	fmt.Fprintf(writer, biggestOrdersDates+"\n")
 	fmt.Fprintf(writer, ordersWhenRateChanged+"\n")
-	fmt.Fprintf(writer, avgNumOrdersLessThen50+"\n")
+	fmt.Fprintf(writer, avgNumOrdersLessThan+"\n")
 	fmt.Fprintf(writer, typesOfSmallestOrders+"\n")
-	fmt.Fprintf(writer, statsFor8HrPeriods+"\n")
+	fmt.Fprintf(writer, statsForPeriods+"\n")
+	fmt.Fprintf(writer, revenueOverTime+"\n")
+	fmt.Fprintf(writer, breakdown+"\n")
+	fmt.Fprintf(writer, manageOrderTypes+"\n")
 	fmt.Fprintf(writer, exitProgram+"\n")
 }
 
-func PrintTable(writer io.Writer, orders []models.Order) {
-	fmt.Fprintf(writer, "\nId	Date and time of order	Order type	"+
-		"Pay amount		Currency 	Exchange rate	\n")
-	for _, order := range orders {
-		fmt.Fprintf(writer, "%d %s %s %f %s %f\n", order.Id, order.TimeStamp, order.Type, order.Amount,
-			order.Currency, order.ExchangeRate)
-	}
+// PrintTable renders the orders, the orders list and the rate changes report.
+func PrintTable(writer io.Writer, renderer Renderer, orders []models.Order) error {
+	return renderer.Render(writer, export.Orders("orders", orders))
 }
 
-func PrintBiggestOrders(writer io.Writer, orders []models.BiggestOrders) {
-	fmt.Fprintf(writer, "\n\tDate\t\tAmount\n")
-	for _, order := range orders {
-		fmt.Fprintf(writer, "%s   %f\n", order.Date.Format("2006-01-02"), order.TotalUah)
+func PrintHistory(writer io.Writer, renderer Renderer, changes []models.OrderChange) error {
+	table := export.Table{
+		Name: "history",
+		Columns: []export.Column{
+			{Name: "changedAt", Kind: export.Timestamp},
+			{Name: "changedBy", Kind: export.Text},
+			{Name: "operation", Kind: export.Text},
+			{Name: "orderId", Kind: export.Integer},
+			{Name: "before", Kind: export.Text},
+			{Name: "after", Kind: export.Text},
+		},
+	}
+	for _, change := range changes {
+		table.Rows = append(table.Rows, []any{change.ChangedAt, change.ChangedBy, change.Operation, change.OrderId,
+			formatOrder(change.Before), formatOrder(change.After)})
 	}
+
+	return renderer.Render(writer, table)
 }
 
-func PrintStats(writer io.Writer, stats []models.PeriodStats) {
-	fmt.Fprintf(writer, "\nTime period\t\t Total\t   Big\t  Small\n")
-	for _, stat := range stats {
-		fmt.Fprintf(writer, "%s\t%5d\t%5d\t%5d\n", stat.TimePeriod, stat.TotalSales, stat.BigSales, stat.SmallSales)
+func formatOrder(order *models.Order) string {
+	if order == nil {
+		return ""
 	}
+	return fmt.Sprintf("%s %s %s %s @ %s", order.TimeStamp.Format(TimeFormat), order.Type,
+		order.Amount.StringFixed(2), order.Currency, order.ExchangeRate.StringFixed(6))
+}
+
+func PrintBiggestOrders(writer io.Writer, renderer Renderer, orders []models.BiggestOrders) error {
+	return renderer.Render(writer, export.BiggestOrders(orders))
+}
+
+func PrintRevenue(writer io.Writer, renderer Renderer, points []models.RevenuePoint) error {
+	return renderer.Render(writer, export.Revenue(points))
+}
+
+func PrintBreakdown(writer io.Writer, renderer Renderer, b models.Breakdown) error {
+	return renderer.Render(writer, export.Breakdown(b))
+}
+
+func PrintStats(writer io.Writer, renderer Renderer, stats []models.PeriodStats) error {
+	return renderer.Render(writer, export.PeriodStats(stats))
+}
+
+func PrintTypes(writer io.Writer, renderer Renderer, orderTypes []string) error {
+	return renderer.Render(writer, export.SmallestTypes(orderTypes))
+}
+
+// PrintOrderTypes renders the order type catalogue.
+func PrintOrderTypes(writer io.Writer, renderer Renderer, types []models.OrderType) error {
+	return renderer.Render(writer, export.OrderTypes(types))
+}
+
+// PrintSmallOrders renders the statistics of the distribution followed by
+// its months.
+func PrintSmallOrders(writer io.Writer, renderer Renderer, d models.MonthlyDistribution) error {
+	return renderer.Render(writer, export.SmallOrders(d), export.MonthlyCounts(d))
 }
 
-func TakeInput(writer io.Writer, reader io.Reader, instruction string) (string, error) {
-	var value string
+// TakeInput prints the instruction and reads one line of input, trimmed of
+// surrounding spaces. The last line may end without a newline.
+func TakeInput(writer io.Writer, reader *bufio.Reader, instruction string) (string, error) {
+	fmt.Fprint(writer, instruction)
 
-	fmt.Fprintf(writer, instruction)
-	_, err := fmt.Fscan(reader, &value)
-	if err != nil {
+	value, err := reader.ReadString('\n')
+	if err != nil && (err != io.EOF || value == "") {
 		return "", fmt.Errorf("failed to scan value: %w", err)
 	}
 
-	return value, nil
+	return strings.TrimSpace(value), nil
 }
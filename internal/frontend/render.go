@@ -0,0 +1,148 @@
+package frontend
+
+import (
+	"coursework/internal/export"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Renderer writes tables of results in one output format. Several tables
+// form one document: a JSON object keyed by table name, or tables separated
+// by a blank line.
+type Renderer interface {
+	Render(writer io.Writer, tables ...export.Table) error
+}
+
+// Formats lists the names accepted by NewRenderer, the default one first.
+var Formats = []string{"text", "markdown", "json", "csv"}
+
+func NewRenderer(format string) (Renderer, error) {
+	switch format {
+	case "text", "":
+		return TextRenderer{}, nil
+	case "markdown", "md":
+		return MarkdownRenderer{}, nil
+	case "json":
+		return JSONRenderer{}, nil
+	case "csv":
+		return CSVRenderer{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown output format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+// TextRenderer aligns the columns with spaces for reading in a terminal.
+type TextRenderer struct{}
+
+func (TextRenderer) Render(writer io.Writer, tables ...export.Table) error {
+	return renderEach(writer, tables, renderText)
+}
+
+func renderText(writer io.Writer, table export.Table) error {
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+
+	cells := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		cells[i] = column.Name
+	}
+	fmt.Fprintln(tw, strings.Join(cells, "\t"))
+
+	for _, row := range table.Rows {
+		for i, column := range table.Columns {
+			cells[i] = textCell(column, row[i])
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("couldn't render table: %w", err)
+	}
+
+	return nil
+}
+
+// MarkdownRenderer writes a GitHub flavoured Markdown table with numbers
+// aligned to the right.
+type MarkdownRenderer struct{}
+
+func (MarkdownRenderer) Render(writer io.Writer, tables ...export.Table) error {
+	return renderEach(writer, tables, renderMarkdown)
+}
+
+func renderMarkdown(writer io.Writer, table export.Table) error {
+	var b strings.Builder
+
+	b.WriteString("|")
+	for _, column := range table.Columns {
+		b.WriteString(" " + escapeMarkdown(column.Name) + " |")
+	}
+	b.WriteString("\n|")
+	for _, column := range table.Columns {
+		switch column.Kind {
+		case export.Integer, export.Number, export.Float:
+			b.WriteString(" ---: |")
+		default:
+			b.WriteString(" --- |")
+		}
+	}
+	b.WriteString("\n")
+
+	for _, row := range table.Rows {
+		b.WriteString("|")
+		for i, column := range table.Columns {
+			b.WriteString(" " + escapeMarkdown(textCell(column, row[i])) + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	if _, err := io.WriteString(writer, b.String()); err != nil {
+		return fmt.Errorf("couldn't render table: %w", err)
+	}
+
+	return nil
+}
+
+// JSONRenderer writes the rows as an array of objects, see export.WriteJSON.
+type JSONRenderer struct{}
+
+func (JSONRenderer) Render(writer io.Writer, tables ...export.Table) error {
+	return export.WriteJSON(writer, tables...)
+}
+
+// CSVRenderer writes the rows with a header, see export.WriteCSV.
+type CSVRenderer struct{}
+
+func (CSVRenderer) Render(writer io.Writer, tables ...export.Table) error {
+	return renderEach(writer, tables, export.WriteCSV)
+}
+
+// renderEach renders the tables one by one with a blank line in between.
+func renderEach(writer io.Writer, tables []export.Table, render func(io.Writer, export.Table) error) error {
+	for i, table := range tables {
+		if i > 0 {
+			if _, err := io.WriteString(writer, "\n"); err != nil {
+				return fmt.Errorf("couldn't render table: %w", err)
+			}
+		}
+		if err := render(writer, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// textCell formats a cell for people rather than programs, so timestamps use
+// TimeFormat.
+func textCell(column export.Column, cell any) string {
+	if t, ok := cell.(time.Time); ok && column.Kind == export.Timestamp {
+		return t.Format(TimeFormat)
+	}
+	return export.FormatCell(column, cell)
+}
+
+func escapeMarkdown(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
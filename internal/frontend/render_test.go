@@ -0,0 +1,96 @@
+package frontend
+
+import (
+	"bytes"
+	"coursework/internal/export"
+	"coursework/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRenderers(t *testing.T) {
+	orders := []models.Order{
+		{
+			Id:           1,
+			TimeStamp:    time.Date(2025, 12, 1, 9, 30, 0, 0, time.UTC),
+			Type:         "харчування",
+			Amount:       decimal.RequireFromString("20"),
+			Currency:     "UAH",
+			ExchangeRate: decimal.NewFromInt(1),
+		},
+		{
+			Id:           12,
+			TimeStamp:    time.Date(2025, 12, 2, 18, 5, 0, 0, time.UTC),
+			Type:         "a|b",
+			Amount:       decimal.RequireFromString("1234.5"),
+			Currency:     "USD",
+			ExchangeRate: decimal.RequireFromString("41.7"),
+		},
+	}
+
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{"text", "" +
+			"id  timestamp            type        amount   currency  exchangeRate  amountUah\n" +
+			"1   2025-12-01 09:30:00  харчування  20.00    UAH       1.000000      20.00\n" +
+			"12  2025-12-02 18:05:00  a|b         1234.50  USD       41.700000     51478.65\n"},
+		{"markdown", "" +
+			"| id | timestamp | type | amount | currency | exchangeRate | amountUah |\n" +
+			"| ---: | --- | --- | ---: | --- | ---: | ---: |\n" +
+			"| 1 | 2025-12-01 09:30:00 | харчування | 20.00 | UAH | 1.000000 | 20.00 |\n" +
+			"| 12 | 2025-12-02 18:05:00 | a\\|b | 1234.50 | USD | 41.700000 | 51478.65 |\n"},
+		{"csv", "" +
+			"id,timestamp,type,amount,currency,exchangeRate,amountUah\n" +
+			"1,2025-12-01T09:30:00Z,харчування,20.00,UAH,1.000000,20.00\n" +
+			"12,2025-12-02T18:05:00Z,a|b,1234.50,USD,41.700000,51478.65\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.format, func(t *testing.T) {
+			renderer, err := NewRenderer(c.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err = PrintTable(&buf, renderer, orders); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := buf.String(); got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+
+	t.Run("several tables", func(t *testing.T) {
+		tables := []export.Table{export.SmallestTypes([]string{"одяг"}), export.SmallestTypes(nil)}
+
+		var buf bytes.Buffer
+		if err := (TextRenderer{}).Render(&buf, tables...); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := buf.String(), "type\nодяг\n\ntype\n"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+
+		buf.Reset()
+		if err := (JSONRenderer{}).Render(&buf, tables...); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); !strings.HasPrefix(got, `{`) {
+			t.Errorf("got %q, want one JSON object", got)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if _, err := NewRenderer("yaml"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -0,0 +1,270 @@
+package importer
+
+import (
+	"coursework/internal/frontend"
+	"coursework/internal/models"
+	"coursework/internal/storage"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Mapping names the CSV column holding each order field.
+type Mapping struct {
+	TimeStamp    string
+	Type         string
+	Amount       string
+	Currency     string
+	ExchangeRate string
+}
+
+// DefaultMapping matches the JSON names of models.Order, so the header of a
+// file written by hand or exported from the API is:
+//
+//	timestamp,type,amount,currency,exchangeRate
+var DefaultMapping = Mapping{
+	TimeStamp:    "timestamp",
+	Type:         "type",
+	Amount:       "amount",
+	Currency:     "currency",
+	ExchangeRate: "exchangeRate",
+}
+
+// ParseMapping reads a mapping written as "field=column" pairs separated by
+// commas, for example "timestamp=Date,amount=Sum". Fields are named as in
+// DefaultMapping, and the ones not mentioned keep their default column.
+func ParseMapping(spec string) (Mapping, error) {
+	m := DefaultMapping
+	fields := map[string]*string{
+		strings.ToLower(DefaultMapping.TimeStamp):    &m.TimeStamp,
+		strings.ToLower(DefaultMapping.Type):         &m.Type,
+		strings.ToLower(DefaultMapping.Amount):       &m.Amount,
+		strings.ToLower(DefaultMapping.Currency):     &m.Currency,
+		strings.ToLower(DefaultMapping.ExchangeRate): &m.ExchangeRate,
+	}
+
+	for pair := range strings.SplitSeq(spec, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field, column = strings.TrimSpace(field), strings.TrimSpace(column)
+		if !ok || column == "" {
+			return Mapping{}, fmt.Errorf("expected field=column, got %q", pair)
+		}
+
+		p, ok := fields[strings.ToLower(field)]
+		if !ok {
+			return Mapping{}, fmt.Errorf("unknown order field %q", field)
+		}
+		*p = column
+	}
+
+	return m, nil
+}
+
+// Options configure ParseCSV. Zero values fall back to DefaultMapping,
+// frontend.TimeFormat, UTC and a comma separator.
+type Options struct {
+	Mapping    Mapping
+	TimeFormat string
+	// Location is the zone of timestamps that don't name their own.
+	Location *time.Location
+	Comma    rune
+	// RateAt looks up the official rate for rows without one. When it is nil
+	// such rows are rejected, unless the currency is UAH.
+	RateAt func(currency string, at time.Time) (decimal.Decimal, error)
+	// Validate checks every parsed order against the business rules, see
+	// storage.OrderStore.ValidateOrder, including the type and the currency.
+	// When it is nil only the numbers and the timestamp are parsed.
+	Validate func(order models.Order) error
+}
+
+// Rejected is a row that failed validation.
+type Rejected struct {
+	Line int
+	Err  error
+}
+
+func (r Rejected) Error() string {
+	return fmt.Sprintf("line %d: %s", r.Line, strings.ReplaceAll(r.Err.Error(), "\n", "; "))
+}
+
+// Result holds the valid orders, ready for storage.OrderStore.ImportOrders,
+// and the rows that were rejected.
+type Result struct {
+	Orders   []models.Order
+	Rejected []Rejected
+}
+
+// ParseCSV reads orders from a CSV file with a header row. Columns are found
+// by the names in opts.Mapping, ignoring case; extra ones are ignored and the
+// exchange rate column may be missing altogether. Amounts and rates may use a
+// decimal comma.
+//
+// Every row is validated on its own, so one bad row doesn't stop the import;
+// an error is returned only when the file itself can't be read.
+func ParseCSV(r io.Reader, opts Options) (Result, error) {
+	if opts.Mapping == (Mapping{}) {
+		opts.Mapping = DefaultMapping
+	}
+	if opts.TimeFormat == "" {
+		opts.TimeFormat = frontend.TimeFormat
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return Result{}, fmt.Errorf("couldn't read orders header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+
+	m := opts.Mapping
+	for _, required := range []string{m.TimeStamp, m.Type, m.Amount, m.Currency} {
+		if _, ok := columns[strings.ToLower(required)]; !ok {
+			return Result{}, fmt.Errorf("couldn't read orders: missing %q column", required)
+		}
+	}
+
+	var result Result
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			result.Rejected = append(result.Rejected, Rejected{Line: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return Result{}, fmt.Errorf("couldn't read orders: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		order, err := parseRecord(record, columns, opts)
+		if isRejection(err) {
+			result.Rejected = append(result.Rejected, Rejected{Line: line, Err: err})
+			continue
+		}
+		if err != nil {
+			return Result{}, fmt.Errorf("couldn't read orders: line %d: %w", line, err)
+		}
+
+		result.Orders = append(result.Orders, order)
+	}
+
+	return result, nil
+}
+
+// errInvalid marks problems with the row itself, as opposed to failures of
+// the rate lookup.
+var errInvalid = errors.New("invalid row")
+
+type fieldError struct {
+	field string
+	err   error
+}
+
+func (e fieldError) Error() string {
+	return e.field + ": " + e.err.Error()
+}
+
+func (e fieldError) Is(target error) bool {
+	return target == errInvalid
+}
+
+func isRejection(err error) bool {
+	var invalid *storage.ValidationError
+	return errors.Is(err, errInvalid) || errors.Is(err, storage.ErrRateNotFound) || errors.As(err, &invalid)
+}
+
+func parseRecord(record []string, columns map[string]int, opts Options) (models.Order, error) {
+	field := func(name string) string {
+		i, ok := columns[strings.ToLower(name)]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	number := func(name string) (decimal.Decimal, error) {
+		return decimal.NewFromString(strings.ReplaceAll(field(name), ",", "."))
+	}
+
+	var order models.Order
+	var errs []error
+	m := opts.Mapping
+
+	timeStamp, err := time.ParseInLocation(opts.TimeFormat, field(m.TimeStamp), opts.Location)
+	if err != nil {
+		errs = append(errs, fieldError{"timestamp", fmt.Errorf("%q doesn't match %q", field(m.TimeStamp), opts.TimeFormat)})
+	}
+	order.TimeStamp = timeStamp.Truncate(time.Second)
+
+	order.Type = field(m.Type)
+
+	order.Amount, err = number(m.Amount)
+	if err != nil || !order.Amount.IsPositive() {
+		errs = append(errs, fieldError{"amount", fmt.Errorf("%q is not a positive number", field(m.Amount))})
+	}
+	order.Amount = order.Amount.Round(2)
+
+	order.Currency = models.NormalizeCurrency(field(m.Currency))
+
+	if field(m.ExchangeRate) != "" {
+		order.ExchangeRate, err = number(m.ExchangeRate)
+		if err != nil || !order.ExchangeRate.IsPositive() {
+			errs = append(errs, fieldError{"exchange rate", fmt.Errorf("%q is not a positive number", field(m.ExchangeRate))})
+		}
+		order.ExchangeRate = order.ExchangeRate.Round(6)
+	}
+
+	if len(errs) > 0 {
+		return order, errors.Join(errs...)
+	}
+
+	if order.ExchangeRate.IsZero() {
+		order.ExchangeRate, err = officialRate(order, opts.RateAt)
+		if err != nil {
+			return order, err
+		}
+	}
+
+	if opts.Validate != nil {
+		err = opts.Validate(order)
+	}
+	return order, err
+}
+
+func officialRate(order models.Order, rateAt func(string, time.Time) (decimal.Decimal, error)) (decimal.Decimal, error) {
+	if order.Currency == "UAH" {
+		return decimal.NewFromInt(1), nil
+	}
+	if rateAt == nil {
+		return decimal.Zero, fieldError{"exchange rate", errors.New("missing")}
+	}
+
+	rate, err := rateAt(order.Currency, order.TimeStamp)
+	if errors.Is(err, storage.ErrRateNotFound) {
+		return decimal.Zero, fmt.Errorf("exchange rate: no official %s rate at %s: %w",
+			order.Currency, order.TimeStamp.Format(time.DateTime), storage.ErrRateNotFound)
+	}
+
+	return rate, err
+}
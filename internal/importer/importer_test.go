@@ -0,0 +1,186 @@
+package importer
+
+import (
+	"coursework/internal/models"
+	"coursework/internal/storage"
+	"coursework/internal/validation"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseCSV(t *testing.T) {
+	rateAt := func(currency string, at time.Time) (decimal.Decimal, error) {
+		if currency == "USD" && !at.Before(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)) {
+			return decimal.RequireFromString("41.7"), nil
+		}
+		return decimal.Zero, fmt.Errorf("no rate: %w", storage.ErrRateNotFound)
+	}
+
+	t.Run("default columns", func(t *testing.T) {
+		input := "timestamp,type,amount,currency,exchangeRate\n" +
+			"2025-12-01 09:30:00,харчування,20,UAH,\n" +
+			"2025-12-01 10:00:00,одяг,100.5,usd,\n" +
+			"2025-12-01 11:00:00,одяг,12,EUR,48.3\n"
+
+		result, err := ParseCSV(strings.NewReader(input), Options{RateAt: rateAt})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Orders) != 3 || len(result.Rejected) != 0 {
+			t.Fatalf("got %+v", result)
+		}
+		if o := result.Orders[1]; o.Currency != "USD" || o.ExchangeRate.String() != "41.7" || o.Amount.String() != "100.5" {
+			t.Errorf("got %+v", o)
+		}
+		if o := result.Orders[0]; o.ExchangeRate.String() != "1" {
+			t.Errorf("got %+v", o)
+		}
+	})
+
+	t.Run("custom columns and time format", func(t *testing.T) {
+		mapping, err := ParseMapping("timestamp=Дата,type=Категорія,amount=Сума,currency=Валюта,exchangeRate=Курс")
+		if err != nil {
+			t.Fatal(err)
+		}
+		input := "\ufeffДата;Сума;Валюта;Категорія;Курс\n01.12.2025 09:30;20,50;UAH;харчування;1\n"
+
+		result, err := ParseCSV(strings.NewReader(input), Options{Mapping: mapping, TimeFormat: "02.01.2006 15:04", Comma: ';'})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Orders) != 1 {
+			t.Fatalf("got %+v", result)
+		}
+		o := result.Orders[0]
+		if o.Amount.String() != "20.5" || o.Type != "харчування" || !o.TimeStamp.Equal(time.Date(2025, 12, 1, 9, 30, 0, 0, time.UTC)) {
+			t.Errorf("got %+v", o)
+		}
+	})
+
+	t.Run("rejected rows", func(t *testing.T) {
+		input := "timestamp,type,amount,currency,exchangeRate\n" +
+			"2025-12-01 09:30:00,харчування,20,UAH,1\n" +
+			"2025-12-01,харчування,-5,UA,1\n" +
+			"2025-11-30 10:00:00,одяг,100,USD,\n" +
+			"2025-12-01 10:00:00,,100,EUR,0\n"
+
+		result, err := ParseCSV(strings.NewReader(input), Options{RateAt: rateAt})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Orders) != 1 {
+			t.Errorf("got %d orders, want 1", len(result.Orders))
+		}
+
+		want := []struct {
+			line   int
+			fields []string
+		}{
+			// the type and the currency are left to Options.Validate
+			{3, []string{"timestamp", "amount"}},
+			{4, []string{"no official USD rate"}},
+			{5, []string{"exchange rate"}},
+		}
+		if len(result.Rejected) != len(want) {
+			t.Fatalf("got %v", result.Rejected)
+		}
+		for i, w := range want {
+			got := result.Rejected[i]
+			if got.Line != w.line {
+				t.Errorf("got line %d, want %d", got.Line, w.line)
+			}
+			for _, field := range w.fields {
+				if !strings.Contains(got.Error(), field) {
+					t.Errorf("line %d: got %q, want it to mention %q", w.line, got.Error(), field)
+				}
+			}
+		}
+	})
+
+	t.Run("missing rate without lookup", func(t *testing.T) {
+		input := "timestamp,type,amount,currency\n2025-12-01 10:00:00,одяг,100,USD\n"
+
+		result, err := ParseCSV(strings.NewReader(input), Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Rejected) != 1 || !strings.Contains(result.Rejected[0].Error(), "exchange rate: missing") {
+			t.Errorf("got %v", result.Rejected)
+		}
+	})
+
+	t.Run("business rules", func(t *testing.T) {
+		input := "timestamp,type,amount,currency,exchangeRate\n" +
+			"2025-12-01 09:30:00,харчування,20,UAH,\n" +
+			"2025-12-01 10:00:00,подарунки,100,XYZ,2\n" +
+			"2025-12-01 11:00:00,одяг,12,UAH,2\n"
+		validator := validation.New(validation.DefaultRules())
+		types := map[string]bool{"харчування": true, "одяг": true}
+		validate := func(order models.Order) error { return validator.Order(order, types) }
+
+		result, err := ParseCSV(strings.NewReader(input), Options{Validate: validate})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Orders) != 1 || len(result.Rejected) != 2 {
+			t.Fatalf("got %+v", result)
+		}
+		if got := result.Rejected[0].Error(); !strings.Contains(got, "line 3") || !strings.Contains(got, "type:") || !strings.Contains(got, "currency:") {
+			t.Errorf("got %s, want the type and currency of line 3", got)
+		}
+		if got := result.Rejected[1].Error(); !strings.Contains(got, "exchange rate: must be 1 for UAH") {
+			t.Errorf("got %s, want the UAH rate", got)
+		}
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		_, err := ParseCSV(strings.NewReader("timestamp,type,amount\n"), Options{})
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("failed rate lookup", func(t *testing.T) {
+		input := "timestamp,type,amount,currency\n2025-12-01 10:00:00,одяг,100,USD\n"
+		failing := func(string, time.Time) (decimal.Decimal, error) {
+			return decimal.Zero, fmt.Errorf("connection refused")
+		}
+
+		_, err := ParseCSV(strings.NewReader(input), Options{RateAt: failing})
+		if err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("got %v, want an error on line 2", err)
+		}
+	})
+}
+
+func TestParseMapping(t *testing.T) {
+	cases := []struct {
+		spec    string
+		want    Mapping
+		wantErr bool
+	}{
+		{"amount=Sum", Mapping{"timestamp", "type", "Sum", "currency", "exchangeRate"}, false},
+		{"ExchangeRate=Rate, timestamp = When", Mapping{"When", "type", "amount", "currency", "Rate"}, false},
+		{"price=Sum", Mapping{}, true},
+		{"amount", Mapping{}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.spec, func(t *testing.T) {
+			got, err := ParseMapping(c.spec)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("got error %v, want error %v", err, c.wantErr)
+			}
+			if got != c.want {
+				t.Errorf("got %+v, want %+v", got, c.want)
+			}
+		})
+	}
+}
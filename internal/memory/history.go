@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"coursework/internal/models"
+	"time"
+)
+
+func (s *Store) OrderHistory(ctx context.Context, orderId int) ([]models.OrderChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var changes []models.OrderChange
+	for _, change := range s.history {
+		if change.OrderId == orderId {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+func (s *Store) ChangesBetween(ctx context.Context, from, to time.Time) ([]models.OrderChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var changes []models.OrderChange
+	for _, change := range s.history {
+		if !change.ChangedAt.Before(from) && change.ChangedAt.Before(to) {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+// recordChange appends a history entry; the caller holds the write lock.
+func (s *Store) recordChange(operation string, before, after *models.Order) {
+	change := models.OrderChange{
+		Id:        int64(len(s.history) + 1),
+		Operation: operation,
+		Before:    before,
+		After:     after,
+		ChangedAt: s.now(),
+		ChangedBy: s.actor,
+	}
+
+	if after != nil {
+		change.OrderId = after.Id
+	} else {
+		change.OrderId = before.Id
+	}
+
+	s.history = append(s.history, change)
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"coursework/internal/models"
+	"testing"
+	"time"
+)
+
+func TestOrderHistory(t *testing.T) {
+	ctx := t.Context()
+	s := NewStore()
+	clock := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time {
+		clock = clock.Add(time.Hour)
+		return clock
+	}
+	s.SetActor("operator")
+
+	mustAdd(t, s, "2025-12-01 10:00:00", "одяг", "100", "UAH", "1")
+	mustAdd(t, s, "2025-12-01 11:00:00", "одяг", "200", "UAH", "1")
+	if err := s.UpdateOrder(ctx, 1, "розваги"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteOrder(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("history of one order", func(t *testing.T) {
+		changes, err := s.OrderHistory(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(changes) != 3 {
+			t.Fatalf("got %d changes, want 3", len(changes))
+		}
+
+		wantOperations := []string{models.OperationInsert, models.OperationUpdate, models.OperationDelete}
+		for i, change := range changes {
+			if change.Operation != wantOperations[i] || change.ChangedBy != "operator" {
+				t.Errorf("change %d: got %s by %s", i, change.Operation, change.ChangedBy)
+			}
+		}
+
+		update := changes[1]
+		if update.Before.Type != "одяг" || update.After.Type != "розваги" {
+			t.Errorf("update: got before %v, after %v", update.Before, update.After)
+		}
+		if changes[0].Before != nil {
+			t.Error("insert must have no before snapshot")
+		}
+		if changes[2].Before.DeletedAt != nil || changes[2].After.DeletedAt == nil {
+			t.Errorf("delete: got before %v, after %v", changes[2].Before, changes[2].After)
+		}
+	})
+
+	t.Run("changes in a window", func(t *testing.T) {
+		from := time.Date(2026, 1, 10, 11, 0, 0, 0, time.UTC)
+		changes, err := s.ChangesBetween(ctx, from, from.Add(2*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(changes) != 2 || changes[0].OrderId != 2 || changes[1].Operation != models.OperationUpdate {
+			t.Errorf("got %+v", changes)
+		}
+	})
+}
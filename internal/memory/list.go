@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"coursework/internal/models"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+func (s *Store) ListOrders(ctx context.Context, query models.OrderQuery) (models.OrderPage, error) {
+	if err := query.Validate(); err != nil {
+		return models.OrderPage{}, fmt.Errorf("error listing orders: %w", err)
+	}
+
+	field := query.SortField()
+	compare := func(a, b models.Order) int {
+		c := compareBy(field, a, b)
+		if c == 0 {
+			c = cmp.Compare(a.Id, b.Id)
+		}
+		if query.Desc {
+			c = -c
+		}
+		return c
+	}
+
+	s.mu.RLock()
+	var orders []models.Order
+	for _, o := range s.orders {
+		if query.Filter.Matches(o) {
+			orders = append(orders, o)
+		}
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(orders, compare)
+
+	if query.After != nil {
+		boundary, err := field.Order(*query.After)
+		if err != nil {
+			return models.OrderPage{}, fmt.Errorf("error listing orders: %w", err)
+		}
+		orders = slices.DeleteFunc(orders, func(o models.Order) bool { return compare(o, boundary) <= 0 })
+	}
+	if query.Before != nil {
+		boundary, err := field.Order(*query.Before)
+		if err != nil {
+			return models.OrderPage{}, fmt.Errorf("error listing orders: %w", err)
+		}
+		orders = slices.DeleteFunc(orders, func(o models.Order) bool { return compare(o, boundary) >= 0 })
+		// read backwards from the cursor, as the database does
+		slices.Reverse(orders)
+	}
+
+	if query.Limit > 0 && len(orders) > query.Limit+1 {
+		orders = orders[:query.Limit+1]
+	}
+
+	return query.Page(orders), nil
+}
+
+func compareBy(field models.SortField, a, b models.Order) int {
+	switch field {
+	case models.SortByTimeStamp:
+		return a.TimeStamp.Compare(b.TimeStamp)
+	case models.SortByType:
+		return strings.Compare(a.Type, b.Type)
+	case models.SortByAmount:
+		return a.Amount.Cmp(b.Amount)
+	case models.SortByCurrency:
+		return strings.Compare(a.Currency, b.Currency)
+	case models.SortByExchangeRate:
+		return a.ExchangeRate.Cmp(b.ExchangeRate)
+	case models.SortByAmountUah:
+		return a.AmountUah().Cmp(b.AmountUah())
+	}
+	return cmp.Compare(a.Id, b.Id)
+}
@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"coursework/internal/models"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func ids(orders []models.Order) []int {
+	var ids []int
+	for _, o := range orders {
+		ids = append(ids, o.Id)
+	}
+	return ids
+}
+
+func TestListOrders(t *testing.T) {
+	ctx := t.Context()
+	s := newTestStore(t)
+
+	t.Run("filter", func(t *testing.T) {
+		minUah := decimal.NewFromInt(1000)
+		cases := []struct {
+			name   string
+			filter models.OrderFilter
+			want   []int
+		}{
+			{"everything", models.OrderFilter{}, []int{1, 2, 3, 4, 5}},
+			{"date range", models.OrderFilter{
+				From: time.Date(2025, 12, 1, 9, 10, 0, 0, time.UTC),
+				To:   time.Date(2025, 12, 9, 4, 53, 0, 0, time.UTC),
+			}, []int{2, 3}},
+			{"type and currency", models.OrderFilter{Type: "харчування", Currency: "uah"}, []int{3, 5}},
+			{"amount in uah", models.OrderFilter{MinAmount: &minUah, InUah: true}, []int{1, 4}},
+			{"native amount", models.OrderFilter{MinAmount: &minUah}, nil},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				page, err := s.ListOrders(ctx, models.OrderQuery{Filter: c.filter})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := ids(page.Orders); !reflect.DeepEqual(got, c.want) {
+					t.Errorf("got %v, want %v", got, c.want)
+				}
+			})
+		}
+	})
+
+	t.Run("sorting with ties broken by id", func(t *testing.T) {
+		page, err := s.ListOrders(ctx, models.OrderQuery{Sort: models.SortByExchangeRate, Desc: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := ids(page.Orders), []int{2, 4, 1, 5, 3}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("paging back and forth", func(t *testing.T) {
+		query := models.OrderQuery{Sort: models.SortByAmountUah, Limit: 2}
+
+		var pages [][]int
+		var page models.OrderPage
+		for {
+			var err error
+			page, err = s.ListOrders(ctx, query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pages = append(pages, ids(page.Orders))
+			if page.Next == "" {
+				break
+			}
+
+			cursor, err := models.DecodeCursor(page.Next)
+			if err != nil {
+				t.Fatal(err)
+			}
+			query.After = &cursor
+		}
+		if want := [][]int{{3, 5}, {2, 4}, {1}}; !reflect.DeepEqual(pages, want) {
+			t.Fatalf("got %v, want %v", pages, want)
+		}
+
+		cursor, err := models.DecodeCursor(page.Prev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		query.After, query.Before = nil, &cursor
+		page, err = s.ListOrders(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := ids(page.Orders), []int{2, 4}; !reflect.DeepEqual(got, want) || page.Prev == "" || page.Next == "" {
+			t.Errorf("got %v with prev %q and next %q, want %v", got, page.Prev, page.Next, want)
+		}
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		if _, err := s.ListOrders(ctx, models.OrderQuery{Sort: "price"}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
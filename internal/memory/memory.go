@@ -0,0 +1,530 @@
+package memory
+
+import (
+	"context"
+	"coursework/internal/models"
+	"coursework/internal/storage"
+	"coursework/internal/validation"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var _ storage.Store = (*Store)(nil)
+
+// Store keeps orders in memory. Its queries mirror the ones in
+// postgres.DbController, so it can stand in for the database offline and in tests.
+type Store struct {
+	mu     sync.RWMutex
+	lastId int
+	orders []models.Order
+	// deleted holds soft-deleted orders, most recently deleted last.
+	deleted []models.Order
+	// rates holds the history of every currency, sorted by EffectiveAt.
+	rates map[string][]models.ExchangeRate
+	// types is the order type catalogue by code.
+	types   map[string]models.OrderType
+	history []models.OrderChange
+	actor   string
+	now     func() time.Time
+	// location is the business time zone order times are kept in.
+	location  *time.Location
+	validator *validation.Validator
+}
+
+func NewStore() *Store {
+	return &Store{
+		types:     defaultTypes(),
+		actor:     "system",
+		now:       time.Now,
+		location:  time.UTC,
+		validator: validation.New(validation.DefaultRules()),
+	}
+}
+
+// SetValidator sets the rules new and changed orders are checked against.
+func (s *Store) SetValidator(validator *validation.Validator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.validator = validator
+}
+
+// SetActor sets the user recorded in the history of subsequent changes.
+func (s *Store) SetActor(actor string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.actor = actor
+}
+
+// SetLocation sets the business time zone and moves the stored orders to it.
+func (s *Store) SetLocation(location *time.Location) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.location = location
+	for _, orders := range [][]models.Order{s.orders, s.deleted} {
+		for i := range orders {
+			orders[i].TimeStamp = orders[i].TimeStamp.In(location)
+		}
+	}
+}
+
+func (s *Store) Location() *time.Location {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.location
+}
+
+func (s *Store) SelectAllOrders(ctx context.Context, limit int) ([]models.Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if limit < 0 {
+		return nil, fmt.Errorf("error selecting all orders: %w", storage.Invalid("limit", "must not be negative, got %d", limit))
+	}
+	if limit == 0 || limit > len(s.orders) {
+		limit = len(s.orders)
+	}
+
+	if limit == 0 {
+		return nil, nil
+	}
+
+	orders := make([]models.Order, limit)
+	copy(orders, s.orders)
+
+	return orders, nil
+}
+
+func (s *Store) AddNewOrder(ctx context.Context, orderDate time.Time, orderType string, amount decimal.Decimal, currency string, exchangerate decimal.Decimal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	currency = models.NormalizeCurrency(currency)
+	order := models.Order{TimeStamp: orderDate, Type: orderType, Amount: amount, Currency: currency, ExchangeRate: exchangerate}
+	if err := s.validator.NewOrder(order, s.activeTypes()); err != nil {
+		return fmt.Errorf("error adding new order: %w", err)
+	}
+
+	if exchangerate.IsZero() {
+		var ok bool
+		if currency == "UAH" {
+			exchangerate, ok = decimal.NewFromInt(1), true
+		} else {
+			exchangerate, ok = s.rateAt(currency, orderDate)
+		}
+		if !ok {
+			return fmt.Errorf("error adding new order: no %s rate at %s: %w",
+				currency, orderDate.Format(time.DateTime), storage.ErrRateNotFound)
+		}
+		if err := s.validator.Rate(currency, exchangerate); err != nil {
+			return fmt.Errorf("error adding new order: official rate: %w", err)
+		}
+	}
+
+	s.lastId++
+	order.Id = s.lastId
+	order.TimeStamp = orderDate.Truncate(time.Second).In(s.location)
+	order.Amount = amount.Round(2)
+	order.ExchangeRate = exchangerate.Round(6)
+	s.orders = append(s.orders, order)
+	s.recordChange(models.OperationInsert, nil, &order)
+
+	return nil
+}
+
+func (s *Store) ValidateOrder(ctx context.Context, order models.Order) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	order.Currency = models.NormalizeCurrency(order.Currency)
+	return s.validator.Order(order, s.activeTypes())
+}
+
+func (s *Store) ImportOrders(ctx context.Context, orders []models.Order) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orders = models.NormalizeCurrencies(orders)
+	if err := s.validator.Import(orders, s.activeTypes()); err != nil {
+		return 0, fmt.Errorf("error importing orders: %w", err)
+	}
+
+	for _, order := range orders {
+		s.lastId++
+		order.Id = s.lastId
+		order.TimeStamp = order.TimeStamp.Truncate(time.Second).In(s.location)
+		order.Amount = order.Amount.Round(2)
+		order.ExchangeRate = order.ExchangeRate.Round(6)
+		order.DeletedAt = nil
+		s.orders = append(s.orders, order)
+		s.recordChange(models.OperationInsert, nil, &order)
+	}
+
+	return len(orders), nil
+}
+
+func (s *Store) GetOrder(ctx context.Context, orderId int) (models.Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.indexOf(orderId)
+	if i < 0 {
+		return models.Order{}, fmt.Errorf("error getting order: row with id %d: %w", orderId, storage.ErrOrderNotFound)
+	}
+
+	return s.orders[i], nil
+}
+
+func (s *Store) UpdateOrder(ctx context.Context, orderId int, orderType string) error {
+	return s.PatchOrder(ctx, orderId, models.OrderPatch{Type: &orderType})
+}
+
+func (s *Store) PatchOrder(ctx context.Context, orderId int, patch models.OrderPatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(orderId)
+	if i < 0 {
+		return fmt.Errorf("error updating order: %w", s.missing(orderId, "is deleted"))
+	}
+
+	before := s.orders[i]
+	patch = patch.Normalized()
+	if err := s.validator.Patch(before, patch, s.activeTypes()); err != nil {
+		return fmt.Errorf("error updating order: %w", err)
+	}
+	order := patch.Apply(before)
+	order.TimeStamp = order.TimeStamp.Truncate(time.Second).In(s.location)
+	order.Amount = order.Amount.Round(2)
+	order.ExchangeRate = order.ExchangeRate.Round(6)
+	s.orders[i] = order
+	s.recordChange(models.OperationUpdate, &before, &order)
+
+	return nil
+}
+
+func (s *Store) DeleteOrder(ctx context.Context, orderId int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(orderId)
+	if i < 0 {
+		return fmt.Errorf("error deleting order: %w", s.missing(orderId, "is already deleted"))
+	}
+
+	before := s.orders[i]
+	after := before
+	deletedAt := s.now()
+	after.DeletedAt = &deletedAt
+	s.orders = append(s.orders[:i], s.orders[i+1:]...)
+	s.deleted = append(s.deleted, after)
+	s.recordChange(models.OperationDelete, &before, &after)
+
+	return nil
+}
+
+// missing explains why an order isn't among the live ones: it was deleted,
+// which conflicts with the change as reason says, or it never existed.
+func (s *Store) missing(orderId int, reason string) error {
+	if slices.ContainsFunc(s.deleted, func(o models.Order) bool { return o.Id == orderId }) {
+		return fmt.Errorf("order %d %s: %w", orderId, reason, storage.ErrConflict)
+	}
+	return fmt.Errorf("row with id %d: %w", orderId, storage.ErrOrderNotFound)
+}
+
+func (s *Store) DeletedOrders(ctx context.Context) ([]models.Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	orders := make([]models.Order, 0, len(s.deleted))
+	for i := len(s.deleted) - 1; i >= 0; i-- {
+		orders = append(orders, s.deleted[i])
+	}
+
+	return orders, nil
+}
+
+func (s *Store) RestoreOrder(ctx context.Context, orderId int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.deleted, func(o models.Order) bool { return o.Id == orderId })
+	if i < 0 {
+		if s.indexOf(orderId) >= 0 {
+			return fmt.Errorf("error restoring order: order %d is not deleted: %w", orderId, storage.ErrConflict)
+		}
+		return fmt.Errorf("error restoring order: row with id %d: %w", orderId, storage.ErrOrderNotFound)
+	}
+
+	before := s.deleted[i]
+	after := before
+	after.DeletedAt = nil
+	s.deleted = slices.Delete(s.deleted, i, i+1)
+
+	// Keep orders sorted by id, the order they were inserted in.
+	at, _ := slices.BinarySearchFunc(s.orders, orderId, func(o models.Order, id int) int { return o.Id - id })
+	s.orders = slices.Insert(s.orders, at, after)
+	s.recordChange(models.OperationRestore, &before, &after)
+
+	return nil
+}
+
+func (s *Store) PurgeDeletedOrders(ctx context.Context, deletedBefore time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.deleted[:0]
+	var purged int
+	for _, o := range s.deleted {
+		if !o.DeletedAt.Before(deletedBefore) {
+			kept = append(kept, o)
+			continue
+		}
+		s.recordChange(models.OperationPurge, &o, nil)
+		purged++
+	}
+	s.deleted = kept
+
+	return purged, nil
+}
+
+func (s *Store) DatesWithBiggestOrders(ctx context.Context, limit int, dates models.DateRange) ([]models.BiggestOrders, error) {
+	if limit == 0 {
+		return nil, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	totals := make(map[time.Time]decimal.Decimal)
+	for _, o := range s.ordersIn(dates) {
+		date := dateOf(o.TimeStamp)
+		totals[date] = totals[date].Add(o.AmountUah())
+	}
+
+	orders := make([]models.BiggestOrders, 0, len(totals))
+	for date, total := range totals {
+		orders = append(orders, models.BiggestOrders{Date: date, TotalUah: total})
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].TotalUah.Equal(orders[j].TotalUah) {
+			return orders[i].TotalUah.GreaterThan(orders[j].TotalUah)
+		}
+		return orders[i].Date.Before(orders[j].Date)
+	})
+
+	if limit > 0 && limit < len(orders) {
+		orders = orders[:limit]
+	}
+
+	return orders, nil
+}
+
+func (s *Store) TypeOfSmallestOrders(ctx context.Context, limit int, dates models.DateRange) ([]string, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("error getting type of smallest orders: %w", storage.Invalid("limit", "must not be negative, got %d", limit))
+	}
+
+	s.mu.RLock()
+	smallest := s.ordersIn(dates)
+	s.mu.RUnlock()
+
+	sort.SliceStable(smallest, func(i, j int) bool {
+		return smallest[i].Amount.LessThan(smallest[j].Amount)
+	})
+	if limit < len(smallest) {
+		smallest = smallest[:limit]
+	}
+
+	seen := make(map[string]bool)
+	var orderTypes []string
+	for _, o := range smallest {
+		if !seen[o.Type] {
+			seen[o.Type] = true
+			orderTypes = append(orderTypes, o.Type)
+		}
+	}
+	sort.Strings(orderTypes)
+
+	return orderTypes, nil
+}
+
+func (s *Store) OrdersWhenRateChanged(ctx context.Context, dates models.DateRange) ([]models.Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	inRange := s.ordersIn(dates)
+
+	type dayCurrency struct {
+		date     time.Time
+		currency string
+	}
+
+	rates := make(map[dayCurrency]map[string]bool)
+	for _, o := range inRange {
+		key := dayCurrency{dateOf(o.TimeStamp), o.Currency}
+		if rates[key] == nil {
+			rates[key] = make(map[string]bool)
+		}
+		rates[key][o.ExchangeRate.String()] = true
+	}
+
+	var orders []models.Order
+	for _, o := range inRange {
+		if len(rates[dayCurrency{dateOf(o.TimeStamp), o.Currency}]) > 1 {
+			orders = append(orders, o)
+		}
+	}
+	sortByDateAndCurrency(orders)
+
+	return orders, nil
+}
+
+func (s *Store) MonthlyDistribution(ctx context.Context, orderType string, lessThen decimal.Decimal, dates models.DateRange) (models.MonthlyDistribution, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byMonth := make(map[time.Time]int)
+	for _, o := range s.ordersIn(dates) {
+		if o.Type != orderType {
+			continue
+		}
+		// months with orders of the type but none small still count
+		small := 0
+		if o.AmountUah().LessThan(lessThen) {
+			small = 1
+		}
+		byMonth[models.ByMonth.Start(o.TimeStamp)] += small
+	}
+
+	counts := make([]models.MonthlyCount, 0, len(byMonth))
+	for month, orders := range byMonth {
+		counts = append(counts, models.MonthlyCount{Month: month, Orders: orders})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Month.Before(counts[j].Month)
+	})
+
+	return models.NewMonthlyDistribution(orderType, lessThen, counts, dates), nil
+}
+
+func (s *Store) GetTableForPeriods(ctx context.Context, buckets models.Buckets, dates models.DateRange) ([]models.PeriodStats, error) {
+	if err := buckets.Validate(); err != nil {
+		return nil, fmt.Errorf("error getting table for stats: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var counts []models.MinuteCount
+	for _, o := range s.ordersIn(dates) {
+		count := models.MinuteCount{Minute: buckets.MinuteOfDay(o.TimeStamp), Total: 1}
+		if o.AmountUah().GreaterThan(buckets.BigSale) {
+			count.BigSales = 1
+		}
+		counts = append(counts, count)
+	}
+
+	return buckets.Stats(counts), nil
+}
+
+func (s *Store) RevenueSeries(ctx context.Context, granularity models.Granularity, dates models.DateRange) ([]models.RevenuePoint, error) {
+	if _, err := models.ParseGranularity(string(granularity)); err != nil {
+		return nil, fmt.Errorf("error getting revenue series: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byPeriod := make(map[time.Time]*models.RevenuePoint)
+	for _, o := range s.ordersIn(dates) {
+		period := granularity.Start(o.TimeStamp)
+		point, ok := byPeriod[period]
+		if !ok {
+			point = &models.RevenuePoint{Period: period}
+			byPeriod[period] = point
+		}
+		point.Orders++
+		point.TotalUah = point.TotalUah.Add(o.AmountUah())
+	}
+
+	totals := make([]models.RevenuePoint, 0, len(byPeriod))
+	for _, point := range byPeriod {
+		totals = append(totals, *point)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		return totals[i].Period.Before(totals[j].Period)
+	})
+
+	return models.RevenueSeries(granularity, totals, dates), nil
+}
+
+func (s *Store) Breakdown(ctx context.Context, dates models.DateRange) (models.Breakdown, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type typeCurrency struct {
+		orderType, currency string
+	}
+
+	var cells []models.BreakdownCell
+	index := make(map[typeCurrency]int)
+	for _, o := range s.ordersIn(dates) {
+		key := typeCurrency{o.Type, o.Currency}
+		i, ok := index[key]
+		if !ok {
+			i = len(cells)
+			index[key] = i
+			cells = append(cells, models.BreakdownCell{Type: o.Type, Currency: o.Currency})
+		}
+		cells[i].Orders++
+		cells[i].Amount = cells[i].Amount.Add(o.Amount)
+		cells[i].AmountUah = cells[i].AmountUah.Add(o.AmountUah())
+	}
+
+	return models.NewBreakdown(cells), nil
+}
+
+// ordersIn returns a copy of the orders placed within dates. The caller
+// holds the lock.
+func (s *Store) ordersIn(dates models.DateRange) []models.Order {
+	var orders []models.Order
+	for _, o := range s.orders {
+		if dates.Contains(o.TimeStamp) {
+			orders = append(orders, o)
+		}
+	}
+	return orders
+}
+
+func (s *Store) indexOf(orderId int) int {
+	for i, o := range s.orders {
+		if o.Id == orderId {
+			return i
+		}
+	}
+	return -1
+}
+
+func sortByDateAndCurrency(orders []models.Order) {
+	sort.SliceStable(orders, func(i, j int) bool {
+		di, dj := dateOf(orders[i].TimeStamp), dateOf(orders[j].TimeStamp)
+		if !di.Equal(dj) {
+			return di.Before(dj)
+		}
+		return orders[i].Currency < orders[j].Currency
+	})
+}
+
+// dateOf returns the calendar date of t in its own zone, as midnight UTC.
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
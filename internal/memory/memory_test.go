@@ -0,0 +1,493 @@
+package memory
+
+import (
+	"coursework/internal/models"
+	"coursework/internal/storage"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func mustAdd(t *testing.T, s *Store, at string, orderType string, amount string, currency string, rate string) {
+	t.Helper()
+
+	ts, err := time.Parse("2006-01-02 15:04:05", at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.AddNewOrder(t.Context(), ts, orderType, decimal.RequireFromString(amount), currency, decimal.RequireFromString(rate))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestStore(t *testing.T) *Store {
+	s := NewStore()
+	mustAdd(t, s, "2025-12-01 07:42:00", "транспорт", "640.24", "USD", "40.587195")
+	mustAdd(t, s, "2025-12-01 17:40:00", "харчування", "20", "USD", "41.2")
+	mustAdd(t, s, "2025-12-01 09:10:00", "харчування", "30", "UAH", "1")
+	mustAdd(t, s, "2025-12-09 04:53:00", "розваги", "394.88", "USD", "40.587195")
+	mustAdd(t, s, "2026-01-05 12:00:00", "харчування", "45", "UAH", "1")
+	return s
+}
+
+func TestDatesWithBiggestOrders(t *testing.T) {
+	ctx := t.Context()
+	s := newTestStore(t)
+
+	got, err := s.DatesWithBiggestOrders(ctx, 2, models.DateRange{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []models.BiggestOrders{
+		{Date: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), TotalUah: decimal.RequireFromString("26839.55")},
+		{Date: time.Date(2025, 12, 9, 0, 0, 0, 0, time.UTC), TotalUah: decimal.RequireFromString("16027.07")},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if !got[i].Date.Equal(want[i].Date) || !got[i].TotalUah.Equal(want[i].TotalUah) {
+			t.Errorf("got %v, want %v", got[i], want[i])
+		}
+	}
+}
+
+func TestBusinessTimeZone(t *testing.T) {
+	ctx := t.Context()
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewStore()
+	s.SetLocation(kyiv)
+	// 23:30 in Kyiv is still December 1, though it is 21:30 UTC
+	at := time.Date(2025, 12, 1, 23, 30, 0, 0, kyiv)
+	if err = s.AddNewOrder(ctx, at.UTC(), "харчування", decimal.NewFromInt(100), "UAH", decimal.Zero); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("orders are shown in the zone", func(t *testing.T) {
+		order, err := s.GetOrder(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := order.TimeStamp.Format(time.DateTime); got != "2025-12-01 23:30:00" {
+			t.Errorf("got %s, want 2025-12-01 23:30:00", got)
+		}
+	})
+
+	t.Run("days are the zone's days", func(t *testing.T) {
+		got, err := s.DatesWithBiggestOrders(ctx, 1, models.DateRange{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+		if len(got) != 1 || !got[0].Date.Equal(want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("periods are split in the zone", func(t *testing.T) {
+		stats, err := s.GetTableForPeriods(ctx, models.DefaultBuckets, models.DateRange{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats) != 3 || stats[2].TotalSales != 1 {
+			t.Errorf("got %v, want the order in the last period", stats)
+		}
+	})
+}
+
+func TestTypeOfSmallestOrders(t *testing.T) {
+	ctx := t.Context()
+	s := newTestStore(t)
+
+	got, err := s.TypeOfSmallestOrders(ctx, 3, models.DateRange{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"харчування"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	var invalid *storage.ValidationError
+	if _, err := s.TypeOfSmallestOrders(ctx, -1, models.DateRange{}); !errors.As(err, &invalid) {
+		t.Errorf("got %v, want a validation error", err)
+	}
+}
+
+func TestOrdersWhenRateChanged(t *testing.T) {
+	ctx := t.Context()
+	s := newTestStore(t)
+
+	orders, err := s.OrdersWhenRateChanged(ctx, models.DateRange{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []int
+	for _, o := range orders {
+		got = append(got, o.Id)
+	}
+
+	want := []int{1, 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMonthlyDistribution(t *testing.T) {
+	ctx := t.Context()
+	threshold := decimal.NewFromInt(50)
+
+	t.Run("months with orders of the type", func(t *testing.T) {
+		got, err := newTestStore(t).MonthlyDistribution(ctx, "харчування", threshold, models.DateRange{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got.Months) != 2 || got.Mean != 1 || got.Median != 1 || got.StdDev != 0 {
+			t.Errorf("got %+v", got)
+		}
+	})
+
+	t.Run("zero months within the date range", func(t *testing.T) {
+		dates := models.DateRange{
+			From: time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		}
+		got, err := newTestStore(t).MonthlyDistribution(ctx, "харчування", threshold, dates)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var counts []int
+		for _, month := range got.Months {
+			counts = append(counts, month.Orders)
+		}
+		if want := []int{0, 1, 1, 0}; !reflect.DeepEqual(counts, want) {
+			t.Errorf("got %v, want %v", counts, want)
+		}
+		if got.Mean != 0.5 || got.Median != 0.5 || got.StdDev != 0.5 {
+			t.Errorf("got mean %v, median %v, stddev %v, want 0.5 each", got.Mean, got.Median, got.StdDev)
+		}
+	})
+
+	t.Run("type is not a pattern", func(t *testing.T) {
+		got, err := newTestStore(t).MonthlyDistribution(ctx, "харч%", threshold, models.DateRange{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Months) != 0 {
+			t.Errorf("got %+v, want no months", got)
+		}
+	})
+
+	t.Run("empty store", func(t *testing.T) {
+		got, err := NewStore().MonthlyDistribution(ctx, "харчування", threshold, models.DateRange{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Months) != 0 || got.Mean != 0 {
+			t.Errorf("got %+v", got)
+		}
+	})
+}
+
+func TestGetTableForPeriods(t *testing.T) {
+	ctx := t.Context()
+	t.Run("default buckets", func(t *testing.T) {
+		s := newTestStore(t)
+
+		got, err := s.GetTableForPeriods(ctx, models.DefaultBuckets, models.DateRange{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []models.PeriodStats{
+			{TimePeriod: "00:00 - 08:00", Start: "00:00", End: "08:00", TotalSales: 2, BigSales: 2, SmallSales: 0},
+			{TimePeriod: "08:00 - 16:00", Start: "08:00", End: "16:00", TotalSales: 2, BigSales: 0, SmallSales: 2},
+			{TimePeriod: "16:00 - 23:59", Start: "16:00", End: "00:00", TotalSales: 1, BigSales: 0, SmallSales: 1},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("custom buckets within a date range", func(t *testing.T) {
+		s := newTestStore(t)
+
+		buckets := models.Buckets{Minutes: 12 * 60, BigSale: decimal.NewFromInt(500)}
+		dates := models.DateRange{
+			From: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2025, 12, 2, 0, 0, 0, 0, time.UTC),
+		}
+		got, err := s.GetTableForPeriods(ctx, buckets, dates)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []models.PeriodStats{
+			{TimePeriod: "00:00 - 12:00", Start: "00:00", End: "12:00", TotalSales: 2, BigSales: 1, SmallSales: 1},
+			{TimePeriod: "12:00 - 23:59", Start: "12:00", End: "00:00", TotalSales: 1, BigSales: 1, SmallSales: 0},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("shifts across midnight in another zone", func(t *testing.T) {
+		s := newTestStore(t)
+
+		kyiv, err := time.LoadLocation("Europe/Kyiv")
+		if err != nil {
+			t.Skip(err)
+		}
+		buckets := models.Buckets{
+			Shifts:   []models.Shift{{Name: "night", Start: 19 * 60, End: 7 * 60}, {Name: "morning", Start: 7 * 60, End: 12 * 60}},
+			Location: kyiv,
+			BigSale:  decimal.NewFromInt(1000),
+		}
+		got, err := s.GetTableForPeriods(ctx, buckets, models.DateRange{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// in Kyiv the orders were placed at 09:42, 19:40, 11:10, 06:53 and 14:00
+		want := []models.PeriodStats{
+			{TimePeriod: "night", Start: "19:00", End: "07:00", TotalSales: 2, BigSales: 1, SmallSales: 1},
+			{TimePeriod: "morning", Start: "07:00", End: "12:00", TotalSales: 2, BigSales: 1, SmallSales: 1},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("invalid buckets", func(t *testing.T) {
+		_, err := newTestStore(t).GetTableForPeriods(ctx, models.Buckets{Minutes: 0}, models.DateRange{})
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestRevenueSeries(t *testing.T) {
+	ctx := t.Context()
+	s := newTestStore(t)
+
+	got, err := s.RevenueSeries(ctx, models.ByMonth, models.DateRange{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %v, want December and January", got)
+	}
+	if got[0].Orders != 4 || got[0].TotalUah.String() != "42866.62" || got[1].Orders != 1 || got[1].TotalUah.String() != "45" {
+		t.Errorf("got %+v", got)
+	}
+
+	if _, err = s.RevenueSeries(ctx, "year", models.DateRange{}); err == nil {
+		t.Error("expected an error for an unknown granularity")
+	}
+}
+
+func TestBreakdown(t *testing.T) {
+	ctx := t.Context()
+	s := newTestStore(t)
+
+	got, err := s.Breakdown(ctx, models.DateRange{To: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var types []string
+	for _, row := range got.Rows {
+		types = append(types, row.Type)
+	}
+	if want := []string{"транспорт", "розваги", "харчування"}; !reflect.DeepEqual(types, want) {
+		t.Errorf("got types %v, want %v", types, want)
+	}
+	if got.Total.Orders != 4 || !reflect.DeepEqual(got.Currencies, []string{"UAH", "USD"}) {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestUpdateAndDeleteOrder(t *testing.T) {
+	ctx := t.Context()
+	s := newTestStore(t)
+
+	if err := s.UpdateOrder(ctx, 2, "одяг"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteOrder(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteOrder(ctx, 1); err == nil {
+		t.Error("expected an error deleting a missing order")
+	}
+
+	orders, _ := s.SelectAllOrders(ctx, 1)
+	if len(orders) != 1 || orders[0].Id != 2 || orders[0].Type != "одяг" {
+		t.Errorf("got %v", orders)
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	ctx := t.Context()
+	s := newTestStore(t)
+	clock := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time {
+		clock = clock.Add(24 * time.Hour)
+		return clock
+	}
+
+	for _, id := range []int{2, 4, 1} {
+		if err := s.DeleteOrder(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("deleted orders are hidden", func(t *testing.T) {
+		if _, err := s.GetOrder(ctx, 2); !errors.Is(err, storage.ErrOrderNotFound) {
+			t.Errorf("got %v, want %v", err, storage.ErrOrderNotFound)
+		}
+
+		types, _ := s.TypeOfSmallestOrders(ctx, 10, models.DateRange{})
+		if want := []string{"харчування"}; !reflect.DeepEqual(types, want) {
+			t.Errorf("got %v, want %v", types, want)
+		}
+
+		deleted, _ := s.DeletedOrders(ctx)
+		if len(deleted) != 3 || deleted[0].Id != 1 || deleted[0].DeletedAt == nil {
+			t.Errorf("got %v", deleted)
+		}
+	})
+
+	t.Run("deleted orders can't be changed", func(t *testing.T) {
+		if err := s.DeleteOrder(ctx, 2); !errors.Is(err, storage.ErrConflict) {
+			t.Errorf("got %v, want %v", err, storage.ErrConflict)
+		}
+		if err := s.UpdateOrder(ctx, 2, "одяг"); !errors.Is(err, storage.ErrConflict) {
+			t.Errorf("got %v, want %v", err, storage.ErrConflict)
+		}
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		if err := s.RestoreOrder(ctx, 4); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.RestoreOrder(ctx, 4); !errors.Is(err, storage.ErrConflict) {
+			t.Errorf("got %v, want %v", err, storage.ErrConflict)
+		}
+		if err := s.RestoreOrder(ctx, 42); !errors.Is(err, storage.ErrOrderNotFound) {
+			t.Errorf("got %v, want %v", err, storage.ErrOrderNotFound)
+		}
+
+		orders, _ := s.SelectAllOrders(ctx, 0)
+		var ids []int
+		for _, o := range orders {
+			ids = append(ids, o.Id)
+		}
+		if want := []int{3, 4, 5}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("got %v, want %v", ids, want)
+		}
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		// Order 2 was deleted on Jan 11 and order 1 on Jan 13.
+		purged, err := s.PurgeDeletedOrders(ctx, time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if purged != 1 {
+			t.Errorf("got %d purged, want 1", purged)
+		}
+
+		deleted, _ := s.DeletedOrders(ctx)
+		if len(deleted) != 1 || deleted[0].Id != 1 {
+			t.Errorf("got %v", deleted)
+		}
+
+		changes, _ := s.OrderHistory(ctx, 2)
+		if last := changes[len(changes)-1]; last.Operation != models.OperationPurge || last.After != nil {
+			t.Errorf("got %+v", last)
+		}
+	})
+}
+
+func TestOrderValidation(t *testing.T) {
+	ctx := t.Context()
+	s := newTestStore(t)
+
+	at := time.Date(2025, 12, 5, 10, 0, 0, 0, time.UTC)
+	err := s.AddNewOrder(ctx, at, "харчування", decimal.Zero, "XYZ", decimal.NewFromInt(2))
+	var invalid *storage.ValidationError
+	if !errors.As(err, &invalid) || len(invalid.Fields) != 2 {
+		t.Errorf("got %v, want the amount and the currency", err)
+	}
+
+	// currencies are stored in upper case whatever case they are entered in
+	if err := s.AddNewOrder(ctx, at, "харчування", decimal.NewFromInt(5), " usd", decimal.NewFromInt(41)); err != nil {
+		t.Fatal(err)
+	}
+	orders, _ := s.SelectAllOrders(ctx, 0)
+	if got := orders[len(orders)-1].Currency; got != "USD" {
+		t.Errorf("got currency %q, want USD", got)
+	}
+
+	if err := s.UpdateOrder(ctx, 1, "подарунки"); !errors.As(err, &invalid) {
+		t.Errorf("got %v, want the type rejected", err)
+	}
+
+	err = s.AddOrderType(ctx, models.OrderType{Code: "подарунки", NameUk: "Подарунки", NameEn: "Gifts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateOrder(ctx, 1, "подарунки"); err != nil {
+		t.Errorf("got %v, want the new type accepted", err)
+	}
+}
+
+func TestOrderTypes(t *testing.T) {
+	ctx := t.Context()
+	s := newTestStore(t)
+
+	err := s.AddOrderType(ctx, models.OrderType{Code: "одяг", NameUk: "Одяг", NameEn: "Clothes"})
+	if !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("got %v, want %v", err, storage.ErrConflict)
+	}
+
+	if err := s.RenameOrderType(ctx, "одяг", "", "Apparel"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeactivateOrderType(ctx, "розваги"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeactivateOrderType(ctx, "подарунки"); !errors.Is(err, storage.ErrOrderTypeNotFound) {
+		t.Errorf("got %v, want %v", err, storage.ErrOrderTypeNotFound)
+	}
+
+	active, _ := s.OrderTypes(ctx, false)
+	all, _ := s.OrderTypes(ctx, true)
+	if len(active) != 4 || len(all) != 5 {
+		t.Errorf("got %d active of %d types, want 4 of 5", len(active), len(all))
+	}
+	if got := all[1]; got.Code != "одяг" || got.NameUk != "Одяг" || got.NameEn != "Apparel" {
+		t.Errorf("got %+v", got)
+	}
+
+	// orders of a deactivated type can still be corrected, but not added
+	at := time.Date(2025, 12, 5, 10, 0, 0, 0, time.UTC)
+	if err := s.AddNewOrder(ctx, at, "розваги", decimal.NewFromInt(10), "UAH", decimal.NewFromInt(1)); err == nil {
+		t.Error("got an order of a deactivated type added")
+	}
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"coursework/internal/models"
+	"coursework/internal/storage"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func (s *Store) AddRates(ctx context.Context, rates []models.ExchangeRate) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rates == nil {
+		s.rates = make(map[string][]models.ExchangeRate)
+	}
+
+	for _, rate := range rates {
+		rate.EffectiveAt = rate.EffectiveAt.Truncate(time.Second)
+		rate.Rate = rate.Rate.Round(6)
+
+		history := s.rates[rate.Currency]
+		i := sort.Search(len(history), func(i int) bool {
+			return !history[i].EffectiveAt.Before(rate.EffectiveAt)
+		})
+
+		if i < len(history) && history[i].EffectiveAt.Equal(rate.EffectiveAt) {
+			history[i] = rate
+			continue
+		}
+
+		history = append(history, models.ExchangeRate{})
+		copy(history[i+1:], history[i:])
+		history[i] = rate
+		s.rates[rate.Currency] = history
+	}
+
+	return len(rates), nil
+}
+
+func (s *Store) RateAt(ctx context.Context, currency string, at time.Time) (decimal.Decimal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rate, ok := s.rateAt(currency, at)
+	if !ok {
+		return decimal.Zero, fmt.Errorf("error getting %s rate at %s: %w",
+			currency, at.Format(time.DateTime), storage.ErrRateNotFound)
+	}
+
+	return rate, nil
+}
+
+func (s *Store) OrdersWhenOfficialRateChanged(ctx context.Context, dates models.DateRange) ([]models.Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type dayCurrency struct {
+		date     time.Time
+		currency string
+	}
+
+	changed := make(map[dayCurrency]bool)
+	for currency, history := range s.rates {
+		for i := 1; i < len(history); i++ {
+			if !history[i].Rate.Equal(history[i-1].Rate) {
+				changed[dayCurrency{dateOf(history[i].EffectiveAt.In(s.location)), currency}] = true
+			}
+		}
+	}
+
+	var orders []models.Order
+	for _, o := range s.ordersIn(dates) {
+		if changed[dayCurrency{dateOf(o.TimeStamp), o.Currency}] {
+			orders = append(orders, o)
+		}
+	}
+	sortByDateAndCurrency(orders)
+
+	return orders, nil
+}
+
+// rateAt is RateAt for callers already holding the lock.
+func (s *Store) rateAt(currency string, at time.Time) (decimal.Decimal, bool) {
+	history := s.rates[currency]
+	i := sort.Search(len(history), func(i int) bool {
+		return history[i].EffectiveAt.After(at)
+	})
+
+	if i == 0 {
+		return decimal.Zero, false
+	}
+
+	return history[i-1].Rate, true
+}
@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"coursework/internal/models"
+	"coursework/internal/storage"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func newRatesStore(t *testing.T) *Store {
+	t.Helper()
+
+	s := NewStore()
+	_, err := s.AddRates(t.Context(), []models.ExchangeRate{
+		{Currency: "USD", EffectiveAt: time.Date(2025, 12, 2, 0, 0, 0, 0, time.UTC), Rate: decimal.RequireFromString("41.5")},
+		{Currency: "USD", EffectiveAt: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), Rate: decimal.RequireFromString("41.2")},
+		{Currency: "USD", EffectiveAt: time.Date(2025, 12, 3, 0, 0, 0, 0, time.UTC), Rate: decimal.RequireFromString("41.5")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestRateAt(t *testing.T) {
+	ctx := t.Context()
+	s := newRatesStore(t)
+
+	got, err := s.RateAt(ctx, "USD", time.Date(2025, 12, 2, 23, 59, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := decimal.RequireFromString("41.5"); !got.Equal(want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	_, err = s.RateAt(ctx, "USD", time.Date(2025, 11, 30, 12, 0, 0, 0, time.UTC))
+	if !errors.Is(err, storage.ErrRateNotFound) {
+		t.Errorf("got %v, want ErrRateNotFound", err)
+	}
+}
+
+func TestAddNewOrderWithOfficialRate(t *testing.T) {
+	ctx := t.Context()
+	s := newRatesStore(t)
+
+	mustAdd(t, s, "2025-12-01 10:00:00", "одяг", "100", "USD", "0")
+	mustAdd(t, s, "2025-12-01 11:00:00", "одяг", "100", "UAH", "0")
+
+	orders, _ := s.SelectAllOrders(ctx, 0)
+	if got := orders[0].ExchangeRate.String(); got != "41.2" {
+		t.Errorf("got USD rate %s, want 41.2", got)
+	}
+	if got := orders[1].ExchangeRate.String(); got != "1" {
+		t.Errorf("got UAH rate %s, want 1", got)
+	}
+
+	at := time.Date(2025, 12, 1, 10, 0, 0, 0, time.UTC)
+	err := s.AddNewOrder(ctx, at, "одяг", decimal.NewFromInt(100), "EUR", decimal.Zero)
+	if !errors.Is(err, storage.ErrRateNotFound) {
+		t.Errorf("got %v, want ErrRateNotFound", err)
+	}
+
+	// a bad official rate is checked like an entered one
+	_, err = s.AddRates(ctx, []models.ExchangeRate{{Currency: "EUR", EffectiveAt: at.Add(-time.Hour), Rate: decimal.NewFromInt(4800)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.AddNewOrder(ctx, at, "одяг", decimal.NewFromInt(100), "EUR", decimal.Zero)
+	var invalid *storage.ValidationError
+	if !errors.As(err, &invalid) || invalid.Fields[0].Field != "exchange rate" {
+		t.Errorf("got %v, want the exchange rate rejected", err)
+	}
+}
+
+func TestOrdersWhenOfficialRateChanged(t *testing.T) {
+	ctx := t.Context()
+	s := newRatesStore(t)
+
+	mustAdd(t, s, "2025-12-01 10:00:00", "одяг", "100", "USD", "41.2")
+	mustAdd(t, s, "2025-12-02 10:00:00", "одяг", "100", "USD", "41.5")
+	mustAdd(t, s, "2025-12-02 12:00:00", "одяг", "100", "UAH", "1")
+	mustAdd(t, s, "2025-12-03 10:00:00", "одяг", "100", "USD", "41.5")
+
+	orders, err := s.OrdersWhenOfficialRateChanged(ctx, models.DateRange{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(orders) != 1 || orders[0].Id != 2 {
+		t.Errorf("got %v, want only order 2", orders)
+	}
+}
@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"coursework/internal/models"
+	"coursework/internal/storage"
+	"coursework/internal/validation"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// defaultTypes mirrors the catalogue the order_types migration starts with.
+func defaultTypes() map[string]models.OrderType {
+	types := make(map[string]models.OrderType)
+	for _, t := range []models.OrderType{
+		{Code: "харчування", NameUk: "Харчування", NameEn: "Food"},
+		{Code: "транспорт", NameUk: "Транспорт", NameEn: "Transport"},
+		{Code: "розваги", NameUk: "Розваги", NameEn: "Entertainment"},
+		{Code: "одяг", NameUk: "Одяг", NameEn: "Clothing"},
+		{Code: "електроніка", NameUk: "Електроніка", NameEn: "Electronics"},
+	} {
+		t.Active = true
+		types[t.Code] = t
+	}
+	return types
+}
+
+func (s *Store) OrderTypes(ctx context.Context, all bool) ([]models.OrderType, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var types []models.OrderType
+	for _, code := range slices.Sorted(maps.Keys(s.types)) {
+		if t := s.types[code]; t.Active || all {
+			types = append(types, t)
+		}
+	}
+
+	return types, nil
+}
+
+func (s *Store) AddOrderType(ctx context.Context, orderType models.OrderType) error {
+	if err := validation.OrderType(orderType); err != nil {
+		return fmt.Errorf("error adding order type: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.types[orderType.Code]; ok {
+		return fmt.Errorf("error adding order type: %q already exists: %w", orderType.Code, storage.ErrConflict)
+	}
+	orderType.NameUk = strings.TrimSpace(orderType.NameUk)
+	orderType.NameEn = strings.TrimSpace(orderType.NameEn)
+	orderType.Active = true
+	s.types[orderType.Code] = orderType
+
+	return nil
+}
+
+func (s *Store) RenameOrderType(ctx context.Context, code, nameUk, nameEn string) error {
+	if err := validation.Rename(nameUk, nameEn); err != nil {
+		return fmt.Errorf("error renaming order type: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.types[code]
+	if !ok {
+		return fmt.Errorf("error renaming order type: %q: %w", code, storage.ErrOrderTypeNotFound)
+	}
+	if nameUk != "" {
+		t.NameUk = strings.TrimSpace(nameUk)
+	}
+	if nameEn != "" {
+		t.NameEn = strings.TrimSpace(nameEn)
+	}
+	s.types[code] = t
+
+	return nil
+}
+
+func (s *Store) DeactivateOrderType(ctx context.Context, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.types[code]
+	if !ok {
+		return fmt.Errorf("error deactivating order type: %q: %w", code, storage.ErrOrderTypeNotFound)
+	}
+	t.Active = false
+	s.types[code] = t
+
+	return nil
+}
+
+// activeTypes returns the codes new and changed orders may use. The caller
+// must hold the lock.
+func (s *Store) activeTypes() map[string]bool {
+	active := make(map[string]bool, len(s.types))
+	for code, t := range s.types {
+		if t.Active {
+			active[code] = true
+		}
+	}
+	return active
+}
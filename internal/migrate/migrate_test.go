@@ -0,0 +1,83 @@
+package migrate
+
+import (
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("embedded migrations are complete", func(t *testing.T) {
+		sub, err := fs.Sub(embedded, "migrations")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		migrations, err := Load(sub)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, m := range migrations {
+			if m.Version != i+1 {
+				t.Errorf("migration %s: got version %d, want %d", m, m.Version, i+1)
+			}
+			// the business zone is configurable, see Migrator.timeZone
+			if strings.Contains(m.Up+m.Down, "Europe/") {
+				t.Errorf("migration %s names a time zone instead of reading app.time_zone", m)
+			}
+			if strings.Contains(m.Down, "DELETE FROM orders") {
+				t.Errorf("migration %s deletes orders when rolled back", m)
+			}
+		}
+	})
+
+	t.Run("sorted by version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"0002_add_index.up.sql":   {Data: []byte("CREATE INDEX")},
+			"0002_add_index.down.sql": {Data: []byte("DROP INDEX")},
+			"0001_init.up.sql":        {Data: []byte("CREATE TABLE")},
+			"0001_init.down.sql":      {Data: []byte("DROP TABLE")},
+			"README.md":               {Data: []byte("ignored")},
+		}
+
+		got, err := Load(fsys)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []Migration{
+			{Version: 1, Name: "init", Up: "CREATE TABLE", Down: "DROP TABLE"},
+			{Version: 2, Name: "add_index", Up: "CREATE INDEX", Down: "DROP INDEX"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("missing down file", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"0001_init.up.sql": {Data: []byte("CREATE TABLE")},
+		}
+
+		if _, err := Load(fsys); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestPendingAndLatest(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+	applied := map[int]time.Time{1: {}, 2: {}}
+
+	if got := pending(migrations, applied); !reflect.DeepEqual(got, []Migration{{Version: 3}}) {
+		t.Errorf("pending: got %v", got)
+	}
+
+	if got := latest(migrations, applied, 5); !reflect.DeepEqual(got, []Migration{{Version: 2}, {Version: 1}}) {
+		t.Errorf("latest: got %v", got)
+	}
+}
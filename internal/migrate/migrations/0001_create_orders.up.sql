@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS orders (
+    id SERIAL PRIMARY KEY,
+    orderDate DATE NOT NULL,
+    orderTime TIME NOT NULL,
+    orderType VARCHAR(50) NOT NULL,
+    amount NUMERIC (15, 2),
+    currency CHAR(3) NOT NULL,
+    exchangeRate NUMERIC (10, 6)
+);
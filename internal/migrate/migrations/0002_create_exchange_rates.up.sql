@@ -0,0 +1,6 @@
+CREATE TABLE exchange_rates (
+    currency CHAR(3) NOT NULL,
+    effective_at TIMESTAMP NOT NULL,
+    rate NUMERIC (10, 6) NOT NULL CHECK (rate > 0),
+    PRIMARY KEY (currency, effective_at)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE order_history (
+    id BIGSERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL,
+    operation VARCHAR(10) NOT NULL CHECK (operation IN ('insert', 'update', 'delete')),
+    before JSONB,
+    after JSONB,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    changed_by VARCHAR(100) NOT NULL
+);
+
+CREATE INDEX order_history_order_id_idx ON order_history (order_id);
+CREATE INDEX order_history_changed_at_idx ON order_history (changed_at);
@@ -0,0 +1,16 @@
+-- Rolling back would lose the deleted orders, so purge or restore them first.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM orders WHERE deleted_at IS NOT NULL) THEN
+        RAISE EXCEPTION 'orders has soft-deleted rows, purge or restore them before rolling back';
+    END IF;
+END
+$$;
+
+DELETE FROM order_history WHERE operation IN ('restore', 'purge');
+
+ALTER TABLE order_history DROP CONSTRAINT order_history_operation_check;
+ALTER TABLE order_history ADD CONSTRAINT order_history_operation_check
+    CHECK (operation IN ('insert', 'update', 'delete'));
+
+ALTER TABLE orders DROP COLUMN deleted_at;
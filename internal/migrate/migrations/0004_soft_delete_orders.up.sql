@@ -0,0 +1,7 @@
+ALTER TABLE orders ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX orders_deleted_at_idx ON orders (deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE order_history DROP CONSTRAINT order_history_operation_check;
+ALTER TABLE order_history ADD CONSTRAINT order_history_operation_check
+    CHECK (operation IN ('insert', 'update', 'delete', 'restore', 'purge'));
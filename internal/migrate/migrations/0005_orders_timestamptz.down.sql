@@ -0,0 +1,19 @@
+UPDATE order_history
+SET before = jsonb_set(before, '{timestamp}',
+        to_jsonb(((before->>'timestamp')::timestamptz AT TIME ZONE current_setting('app.time_zone')) AT TIME ZONE 'UTC'))
+WHERE before ? 'timestamp';
+UPDATE order_history
+SET after = jsonb_set(after, '{timestamp}',
+        to_jsonb(((after->>'timestamp')::timestamptz AT TIME ZONE current_setting('app.time_zone')) AT TIME ZONE 'UTC'))
+WHERE after ? 'timestamp';
+
+ALTER TABLE exchange_rates ALTER COLUMN effective_at TYPE TIMESTAMP
+    USING effective_at AT TIME ZONE current_setting('app.time_zone');
+
+DROP INDEX orders_ordered_at_idx;
+
+ALTER TABLE orders ADD COLUMN orderDate DATE, ADD COLUMN orderTime TIME;
+UPDATE orders SET orderdate = (ordered_at AT TIME ZONE current_setting('app.time_zone'))::date,
+                  ordertime = (ordered_at AT TIME ZONE current_setting('app.time_zone'))::time;
+ALTER TABLE orders ALTER COLUMN orderdate SET NOT NULL, ALTER COLUMN ordertime SET NOT NULL;
+ALTER TABLE orders DROP COLUMN ordered_at;
@@ -0,0 +1,21 @@
+-- Times so far were wall clock times of the business time zone, which the
+-- migrator passes in as app.time_zone.
+ALTER TABLE orders ADD COLUMN ordered_at TIMESTAMPTZ;
+UPDATE orders SET ordered_at = (orderdate + ordertime) AT TIME ZONE current_setting('app.time_zone');
+ALTER TABLE orders ALTER COLUMN ordered_at SET NOT NULL;
+ALTER TABLE orders DROP COLUMN orderdate, DROP COLUMN ordertime;
+
+CREATE INDEX orders_ordered_at_idx ON orders (ordered_at);
+
+ALTER TABLE exchange_rates ALTER COLUMN effective_at TYPE TIMESTAMPTZ
+    USING effective_at AT TIME ZONE current_setting('app.time_zone');
+
+-- Snapshots wrote the wall clock time as if it were UTC.
+UPDATE order_history
+SET before = jsonb_set(before, '{timestamp}',
+        to_jsonb(((before->>'timestamp')::timestamptz AT TIME ZONE 'UTC') AT TIME ZONE current_setting('app.time_zone')))
+WHERE before ? 'timestamp';
+UPDATE order_history
+SET after = jsonb_set(after, '{timestamp}',
+        to_jsonb(((after->>'timestamp')::timestamptz AT TIME ZONE 'UTC') AT TIME ZONE current_setting('app.time_zone')))
+WHERE after ? 'timestamp';
@@ -0,0 +1,4 @@
+-- The mapping of the free-text values can't be undone, orders keep the codes.
+ALTER TABLE orders DROP CONSTRAINT orders_ordertype_fkey;
+
+DROP TABLE order_types;
@@ -0,0 +1,48 @@
+-- Order types were free text. The code is what orders refer to; the display
+-- names can be renamed without touching the orders.
+CREATE TABLE order_types (
+    code VARCHAR(50) PRIMARY KEY,
+    name_uk VARCHAR(100) NOT NULL,
+    name_en VARCHAR(100) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+INSERT INTO order_types (code, name_uk, name_en) VALUES
+    ('харчування', 'Харчування', 'Food'),
+    ('транспорт', 'Транспорт', 'Transport'),
+    ('розваги', 'Розваги', 'Entertainment'),
+    ('одяг', 'Одяг', 'Clothing'),
+    ('електроніка', 'Електроніка', 'Electronics');
+
+-- Map the free-text values onto the catalogue, ignoring case and spaces:
+-- first by code or display name, then by the aliases seen in the data.
+UPDATE orders o SET ordertype = t.code
+FROM order_types t
+WHERE lower(trim(o.ordertype)) IN (t.code, lower(t.name_uk), lower(t.name_en))
+  AND o.ordertype <> t.code;
+
+UPDATE orders o SET ordertype = a.code
+FROM (VALUES
+    ('їжа', 'харчування'),
+    ('продукти', 'харчування'),
+    ('groceries', 'харчування'),
+    ('таксі', 'транспорт'),
+    ('taxi', 'транспорт'),
+    ('clothes', 'одяг'),
+    ('техніка', 'електроніка')
+) AS a (alias, code)
+WHERE lower(trim(o.ordertype)) = a.alias;
+
+-- Values matching nothing become types of their own, so no order is lost.
+INSERT INTO order_types (code, name_uk, name_en)
+SELECT lower(trim(ordertype)), min(trim(ordertype)), min(trim(ordertype))
+FROM orders
+WHERE ordertype NOT IN (SELECT code FROM order_types)
+GROUP BY lower(trim(ordertype))
+ON CONFLICT (code) DO NOTHING;
+
+UPDATE orders SET ordertype = lower(trim(ordertype))
+WHERE ordertype NOT IN (SELECT code FROM order_types);
+
+ALTER TABLE orders ADD CONSTRAINT orders_ordertype_fkey
+    FOREIGN KEY (ordertype) REFERENCES order_types (code);
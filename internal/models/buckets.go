@@ -0,0 +1,177 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const minutesPerDay = 24 * 60
+
+// Shift is a named part of the day from Start to End, in minutes after
+// midnight. A shift ending before it starts crosses midnight.
+type Shift struct {
+	Name  string `json:"name"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// ParseShift reads a shift written as "name=06-12" or "night=22:30-06:00".
+func ParseShift(raw string) (Shift, error) {
+	name, bounds, ok := strings.Cut(raw, "=")
+	start, end, ok2 := strings.Cut(bounds, "-")
+	if !ok || !ok2 || strings.TrimSpace(name) == "" {
+		return Shift{}, fmt.Errorf("invalid shift %q, expected name=HH:MM-HH:MM", raw)
+	}
+
+	shift := Shift{Name: strings.TrimSpace(name)}
+	var err error
+	if shift.Start, err = parseMinuteOfDay(start); err != nil {
+		return Shift{}, fmt.Errorf("invalid shift %q: %w", raw, err)
+	}
+	if shift.End, err = parseMinuteOfDay(end); err != nil {
+		return Shift{}, fmt.Errorf("invalid shift %q: %w", raw, err)
+	}
+
+	return shift, nil
+}
+
+func (s Shift) String() string {
+	return fmt.Sprintf("%s=%s-%s", s.Name, formatMinuteOfDay(s.Start), formatMinuteOfDay(s.End))
+}
+
+// Contains reports whether minute, counted from midnight, falls in the shift.
+func (s Shift) Contains(minute int) bool {
+	if s.Start < s.End {
+		return minute >= s.Start && minute < s.End
+	}
+	return minute >= s.Start || minute < s.End
+}
+
+// parseMinuteOfDay reads "HH" or "HH:MM"; "24" and "24:00" mean midnight at
+// the end of the day.
+func parseMinuteOfDay(raw string) (int, error) {
+	hours, minutes, hasMinutes := strings.Cut(strings.TrimSpace(raw), ":")
+	h, err := strconv.Atoi(hours)
+	m := 0
+	if err == nil && hasMinutes {
+		m, err = strconv.Atoi(minutes)
+	}
+	if err != nil || h < 0 || m < 0 || m > 59 || h*60+m > minutesPerDay {
+		return 0, fmt.Errorf("invalid time of day %q", raw)
+	}
+	return (h*60 + m) % minutesPerDay, nil
+}
+
+func formatMinuteOfDay(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+// Buckets split the day for GetTableForPeriods, either into the named Shifts
+// or, without shifts, into periods of Minutes minutes where the last one ends
+// at midnight even if it is shorter. Orders are placed in the day of
+// Location, the business time zone of the store when it is nil, and count as big sales above BigSale in UAH.
+type Buckets struct {
+	Minutes  int             `json:"minutes,omitempty"`
+	Shifts   []Shift         `json:"shifts,omitempty"`
+	Location *time.Location  `json:"-"`
+	BigSale  decimal.Decimal `json:"bigSale"`
+}
+
+// DefaultBuckets are three 8-hour periods of the business day with a 1000 UAH
+// big sale cutoff.
+var DefaultBuckets = Buckets{Minutes: 8 * 60, BigSale: decimal.NewFromInt(1000)}
+
+func (b Buckets) Validate() error {
+	var errs []error
+	if len(b.Shifts) == 0 && (b.Minutes < 1 || b.Minutes > minutesPerDay) {
+		errs = append(errs, fmt.Errorf("bucket minutes: must be 1 to %d, got %d", minutesPerDay, b.Minutes))
+	}
+	for i, shift := range b.Shifts {
+		if shift.Start == shift.End {
+			errs = append(errs, fmt.Errorf("shift %s: must not be empty", shift.Name))
+		}
+		if slices.ContainsFunc(b.Shifts[:i], func(other Shift) bool { return other.Name == shift.Name }) {
+			errs = append(errs, fmt.Errorf("shift %s: defined twice", shift.Name))
+		}
+	}
+	if b.BigSale.IsNegative() {
+		errs = append(errs, fmt.Errorf("big sale: must not be negative, got %s", b.BigSale))
+	}
+	return errors.Join(errs...)
+}
+
+// MinuteOfDay returns the minutes from midnight to t in the buckets' zone,
+// or in t's own zone when Location is nil.
+func (b Buckets) MinuteOfDay(t time.Time) int {
+	if b.Location != nil {
+		t = t.In(b.Location)
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+// Index returns the bucket of minute, counted from midnight, or -1 when it
+// falls in no shift. Overlapping shifts give the minute to the first one.
+func (b Buckets) Index(minute int) int {
+	if len(b.Shifts) > 0 {
+		return slices.IndexFunc(b.Shifts, func(s Shift) bool { return s.Contains(minute) })
+	}
+	return minute / b.Minutes
+}
+
+// Periods returns an empty PeriodStats for every bucket, in order.
+func (b Buckets) Periods() []PeriodStats {
+	var periods []PeriodStats
+	for _, shift := range b.Shifts {
+		periods = append(periods, PeriodStats{
+			TimePeriod: shift.Name,
+			Start:      formatMinuteOfDay(shift.Start),
+			End:        formatMinuteOfDay(shift.End),
+		})
+	}
+	if len(b.Shifts) > 0 {
+		return periods
+	}
+
+	for start := 0; start < minutesPerDay; start += b.Minutes {
+		end := min(start+b.Minutes, minutesPerDay)
+		label := formatMinuteOfDay(start) + " - " + formatMinuteOfDay(end)
+		if end == minutesPerDay {
+			label = formatMinuteOfDay(start) + " - 23:59"
+		}
+		periods = append(periods, PeriodStats{
+			TimePeriod: label,
+			Start:      formatMinuteOfDay(start),
+			End:        formatMinuteOfDay(end % minutesPerDay),
+		})
+	}
+	return periods
+}
+
+// MinuteCount counts the orders placed in one minute of the day.
+type MinuteCount struct {
+	Minute   int
+	Total    int
+	BigSales int
+}
+
+// Stats sums the counts into the buckets, which all appear even without
+// orders. Orders outside every shift are left out.
+func (b Buckets) Stats(counts []MinuteCount) []PeriodStats {
+	stats := b.Periods()
+	for _, count := range counts {
+		i := b.Index(count.Minute)
+		if i < 0 {
+			continue
+		}
+		stats[i].TotalSales += count.Total
+		stats[i].BigSales += count.BigSales
+		stats[i].SmallSales += count.Total - count.BigSales
+	}
+	return stats
+}
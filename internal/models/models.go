@@ -1,24 +1,147 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
 
+	"github.com/shopspring/decimal"
+)
+
+// Money amounts and exchange rates are exact decimals, matching the
+// NUMERIC(15, 2) and NUMERIC(10, 6) columns they are stored in.
 type Order struct {
-	Id           int
-	TimeStamp    time.Time
-	Type         string
-	Amount       float64
-	Currency     string
-	ExchangeRate float64
+	Id           int             `json:"id"`
+	TimeStamp    time.Time       `json:"timestamp"`
+	Type         string          `json:"type"`
+	Amount       decimal.Decimal `json:"amount"`
+	Currency     string          `json:"currency"`
+	ExchangeRate decimal.Decimal `json:"exchangeRate"`
+	// DeletedAt is set once the order is soft-deleted.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// OrderType is an entry of the order type catalogue. Orders refer to it by
+// Code; inactive types stay on old orders but can't be used in new ones.
+type OrderType struct {
+	Code   string `json:"code"`
+	NameUk string `json:"nameUk"`
+	NameEn string `json:"nameEn"`
+	Active bool   `json:"active"`
+}
+
+// NormalizeCurrency returns a currency code the way orders store it: trimmed
+// and in upper case, so "usd" is accepted as USD on every write path.
+func NormalizeCurrency(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// NormalizeCurrencies returns a copy of orders with their currencies
+// normalized, see NormalizeCurrency.
+func NormalizeCurrencies(orders []Order) []Order {
+	normalized := make([]Order, len(orders))
+	for i, o := range orders {
+		o.Currency = NormalizeCurrency(o.Currency)
+		normalized[i] = o
+	}
+	return normalized
+}
+
+// AmountUah is the order amount converted to hryvnias, see ToUah.
+func (o Order) AmountUah() decimal.Decimal {
+	return ToUah(o.Amount, o.ExchangeRate)
+}
+
+// OrderPatch lists the order fields to change; nil fields are kept as they are.
+type OrderPatch struct {
+	TimeStamp    *time.Time       `json:"timestamp,omitempty"`
+	Type         *string          `json:"type,omitempty"`
+	Amount       *decimal.Decimal `json:"amount,omitempty"`
+	Currency     *string          `json:"currency,omitempty"`
+	ExchangeRate *decimal.Decimal `json:"exchangeRate,omitempty"`
+}
+
+func (p OrderPatch) IsEmpty() bool {
+	return p.TimeStamp == nil && p.Type == nil && p.Amount == nil && p.Currency == nil && p.ExchangeRate == nil
+}
+
+// Normalized returns the patch with its currency normalized, see
+// NormalizeCurrency.
+func (p OrderPatch) Normalized() OrderPatch {
+	if p.Currency != nil {
+		currency := NormalizeCurrency(*p.Currency)
+		p.Currency = &currency
+	}
+	return p
+}
+
+// Apply returns a copy of order with the patch applied.
+func (p OrderPatch) Apply(order Order) Order {
+	if p.TimeStamp != nil {
+		order.TimeStamp = *p.TimeStamp
+	}
+	if p.Type != nil {
+		order.Type = *p.Type
+	}
+	if p.Amount != nil {
+		order.Amount = *p.Amount
+	}
+	if p.Currency != nil {
+		order.Currency = *p.Currency
+	}
+	if p.ExchangeRate != nil {
+		order.ExchangeRate = *p.ExchangeRate
+	}
+	return order
+}
+
+// Operations recorded in the order history.
+const (
+	OperationInsert  = "insert"
+	OperationUpdate  = "update"
+	OperationDelete  = "delete"
+	OperationRestore = "restore"
+	OperationPurge   = "purge"
+)
+
+// OrderChange is one entry of the order history. Before is nil for inserts
+// and After is nil for purges.
+type OrderChange struct {
+	Id        int64     `json:"id"`
+	OrderId   int       `json:"orderId"`
+	Operation string    `json:"operation"`
+	Before    *Order    `json:"before"`
+	After     *Order    `json:"after"`
+	ChangedAt time.Time `json:"changedAt"`
+	ChangedBy string    `json:"changedBy"`
+}
+
+// ExchangeRate is an official rate of a currency to UAH, in effect from
+// EffectiveAt until the next rate of the same currency.
+type ExchangeRate struct {
+	Currency    string          `json:"currency"`
+	EffectiveAt time.Time       `json:"effectiveAt"`
+	Rate        decimal.Decimal `json:"rate"`
 }
 
 type BiggestOrders struct {
-	Date     time.Time
-	TotalUah float64
+	Date     time.Time       `json:"date"`
+	TotalUah decimal.Decimal `json:"totalUah"`
 }
 
+// PeriodStats counts the sales of one bucket of the day, which starts at
+// Start and ends at End, both written as "HH:MM".
 type PeriodStats struct {
-	TimePeriod string
-	TotalSales int
-	BigSales   int
-	SmallSales int
+	TimePeriod string `json:"timePeriod"`
+	Start      string `json:"start"`
+	End        string `json:"end"`
+	TotalSales int    `json:"totalSales"`
+	BigSales   int    `json:"bigSales"`
+	SmallSales int    `json:"smallSales"`
+}
+
+// ToUah converts amount to hryvnias at rate and rounds the result to whole
+// kopecks, half away from zero. This is the rounding Postgres applies in
+// ROUND(numeric, 2), so Go and SQL conversions agree to the kopeck.
+func ToUah(amount, rate decimal.Decimal) decimal.Decimal {
+	return amount.Mul(rate).Round(2)
 }
@@ -0,0 +1,173 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestToUah(t *testing.T) {
+	cases := []struct {
+		amount, rate, want string
+	}{
+		{"640.24", "40.587195", "25985.55"},
+		{"0.01", "41.25", "0.41"},
+		{"0.10", "0.125", "0.01"},
+		{"-0.10", "0.125", "-0.01"},
+		{"1639.97", "1", "1639.97"},
+	}
+
+	for _, c := range cases {
+		got := ToUah(decimal.RequireFromString(c.amount), decimal.RequireFromString(c.rate))
+		if !got.Equal(decimal.RequireFromString(c.want)) {
+			t.Errorf("ToUah(%s, %s) = %s, want %s", c.amount, c.rate, got, c.want)
+		}
+	}
+}
+
+func TestBucketsPeriods(t *testing.T) {
+	cases := []struct {
+		minutes int
+		want    []string
+	}{
+		{8 * 60, []string{"00:00 - 08:00", "08:00 - 16:00", "16:00 - 23:59"}},
+		{10 * 60, []string{"00:00 - 10:00", "10:00 - 20:00", "20:00 - 23:59"}},
+		{24 * 60, []string{"00:00 - 23:59"}},
+	}
+
+	for _, c := range cases {
+		var got []string
+		for _, period := range (Buckets{Minutes: c.minutes}).Periods() {
+			got = append(got, period.TimePeriod)
+		}
+		if strings.Join(got, ",") != strings.Join(c.want, ",") {
+			t.Errorf("%d minutes: got %v, want %v", c.minutes, got, c.want)
+		}
+	}
+}
+
+func TestParseShift(t *testing.T) {
+	t.Run("across midnight", func(t *testing.T) {
+		shift, err := ParseShift("night=22:30-06")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if shift != (Shift{Name: "night", Start: 22*60 + 30, End: 6 * 60}) {
+			t.Errorf("got %+v", shift)
+		}
+		if !shift.Contains(23*60) || !shift.Contains(0) || shift.Contains(6*60) || shift.Contains(12*60) {
+			t.Errorf("%v contains the wrong minutes", shift)
+		}
+	})
+
+	for _, raw := range []string{"night", "=06-12", "morning=06", "morning=6:60-12", "morning=25-26"} {
+		if _, err := ParseShift(raw); err == nil {
+			t.Errorf("%q: expected an error", raw)
+		}
+	}
+}
+
+func TestRevenueSeries(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 12, d, 0, 0, 0, 0, time.UTC) }
+	totals := []RevenuePoint{
+		{Period: day(1), Orders: 2, TotalUah: decimal.NewFromInt(300)},
+		{Period: day(15), Orders: 1, TotalUah: decimal.NewFromInt(450)},
+	}
+
+	t.Run("weeks with a gap", func(t *testing.T) {
+		got := RevenueSeries(ByWeek, totals, DateRange{})
+
+		want := []struct {
+			period             time.Time
+			orders             int
+			total, avg, change string
+			changePercent      string
+		}{
+			{day(1), 2, "300", "150", "0", ""},
+			{day(8), 0, "0", "0", "-300", "-100"},
+			{day(15), 1, "450", "450", "450", ""},
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %v, want %d periods", got, len(want))
+		}
+		for i, w := range want {
+			g := got[i]
+			var changePercent string
+			if g.ChangePercent != nil {
+				changePercent = g.ChangePercent.String()
+			}
+			if !g.Period.Equal(w.period) || g.Orders != w.orders || g.TotalUah.String() != w.total ||
+				g.AvgUah.String() != w.avg || g.Change.String() != w.change || changePercent != w.changePercent {
+				t.Errorf("period %d: got %+v, want %+v", i, g, w)
+			}
+		}
+	})
+
+	t.Run("spanning the date range", func(t *testing.T) {
+		got := RevenueSeries(ByMonth, totals[:1], DateRange{From: day(1), To: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)})
+
+		if len(got) != 2 || !got[1].Period.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) || got[1].Orders != 0 {
+			t.Errorf("got %+v, want December and an empty January", got)
+		}
+	})
+
+	t.Run("no orders", func(t *testing.T) {
+		if got := RevenueSeries(ByDay, nil, DateRange{}); got != nil {
+			t.Errorf("got %+v, want nil", got)
+		}
+	})
+}
+
+func TestGranularityStart(t *testing.T) {
+	// 2025-12-07 is a Sunday, its ISO week starts on Monday 2025-12-01
+	at := time.Date(2025, 12, 7, 18, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		g    Granularity
+		want time.Time
+	}{
+		{ByDay, time.Date(2025, 12, 7, 0, 0, 0, 0, time.UTC)},
+		{ByWeek, time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{ByMonth, time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		if got := c.g.Start(at); !got.Equal(c.want) {
+			t.Errorf("%s: got %v, want %v", c.g, got, c.want)
+		}
+	}
+}
+
+func TestNewBreakdown(t *testing.T) {
+	cell := func(orderType, currency string, orders int, amount, amountUah string) BreakdownCell {
+		return BreakdownCell{Type: orderType, Currency: currency, Orders: orders,
+			Amount: decimal.RequireFromString(amount), AmountUah: decimal.RequireFromString(amountUah)}
+	}
+
+	b := NewBreakdown([]BreakdownCell{
+		cell("харчування", "UAH", 2, "50", "50"),
+		cell("розваги", "USD", 1, "10", "410"),
+		cell("харчування", "USD", 1, "1", "40"),
+	})
+
+	if got, want := strings.Join(b.Currencies, ","), "UAH,USD"; got != want {
+		t.Errorf("got currencies %s, want %s", got, want)
+	}
+	if len(b.Rows) != 2 || b.Rows[0].Type != "розваги" || b.Rows[1].Type != "харчування" {
+		t.Fatalf("got rows %+v, want розваги then харчування", b.Rows)
+	}
+
+	food := b.Rows[1]
+	if food.Cells[0].Orders != 2 || food.Cells[1].Orders != 1 || food.Total.Orders != 3 ||
+		food.Total.AmountUah.String() != "90" || food.Share.String() != "18" {
+		t.Errorf("got %+v", food)
+	}
+	if b.Rows[0].Cells[0].Orders != 0 || b.Rows[0].Share.String() != "82" {
+		t.Errorf("got %+v", b.Rows[0])
+	}
+	if b.Totals[1].Orders != 2 || b.Totals[1].Amount.String() != "11" || b.Total.AmountUah.String() != "500" {
+		t.Errorf("got totals %+v and %+v", b.Totals, b.Total)
+	}
+}
@@ -0,0 +1,257 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// OrderFilter narrows an order listing; zero fields don't filter.
+type OrderFilter struct {
+	// From and To bound the order time to [From, To).
+	From     time.Time `json:"from,omitzero"`
+	To       time.Time `json:"to,omitzero"`
+	Type     string    `json:"type,omitempty"`
+	Currency string    `json:"currency,omitempty"`
+	// MinAmount and MaxAmount are inclusive bounds of the amount, in the
+	// order currency or, with InUah, in hryvnias.
+	MinAmount *decimal.Decimal `json:"minAmount,omitempty"`
+	MaxAmount *decimal.Decimal `json:"maxAmount,omitempty"`
+	InUah     bool             `json:"inUah,omitempty"`
+}
+
+// Matches reports whether the order passes the filter.
+func (f OrderFilter) Matches(o Order) bool {
+	if !f.From.IsZero() && o.TimeStamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !o.TimeStamp.Before(f.To) {
+		return false
+	}
+	if f.Type != "" && o.Type != f.Type {
+		return false
+	}
+	if f.Currency != "" && !strings.EqualFold(o.Currency, f.Currency) {
+		return false
+	}
+
+	amount := o.Amount
+	if f.InUah {
+		amount = o.AmountUah()
+	}
+	if f.MinAmount != nil && amount.LessThan(*f.MinAmount) {
+		return false
+	}
+	if f.MaxAmount != nil && amount.GreaterThan(*f.MaxAmount) {
+		return false
+	}
+
+	return true
+}
+
+// SortField is an order column a listing can be sorted by.
+type SortField string
+
+const (
+	SortById           SortField = "id"
+	SortByTimeStamp    SortField = "timestamp"
+	SortByType         SortField = "type"
+	SortByAmount       SortField = "amount"
+	SortByCurrency     SortField = "currency"
+	SortByExchangeRate SortField = "exchangeRate"
+	SortByAmountUah    SortField = "amountUah"
+)
+
+// SortFields lists every SortField, named as the JSON fields of Order.
+var SortFields = []SortField{SortById, SortByTimeStamp, SortByType, SortByAmount, SortByCurrency, SortByExchangeRate, SortByAmountUah}
+
+func ParseSortField(raw string) (SortField, error) {
+	for _, field := range SortFields {
+		if strings.EqualFold(raw, string(field)) {
+			return field, nil
+		}
+	}
+	return "", fmt.Errorf("unknown sort field %q", raw)
+}
+
+// Cursor marks the boundary row of a page: its value of the sort column and
+// its id, which breaks ties.
+type Cursor struct {
+	Value string `json:"v"`
+	Id    int    `json:"id"`
+}
+
+// cursorTimeFormat keeps the offset, so Postgres reads the cursor as the same
+// instant whatever its session time zone.
+const cursorTimeFormat = "2006-01-02 15:04:05.999999Z07:00"
+
+// CursorOf returns the cursor of order for a listing sorted by field.
+func (field SortField) CursorOf(o Order) Cursor {
+	var value string
+	switch field {
+	case SortById:
+		value = strconv.Itoa(o.Id)
+	case SortByTimeStamp:
+		value = o.TimeStamp.Format(cursorTimeFormat)
+	case SortByType:
+		value = o.Type
+	case SortByAmount:
+		value = o.Amount.String()
+	case SortByCurrency:
+		value = o.Currency
+	case SortByExchangeRate:
+		value = o.ExchangeRate.String()
+	case SortByAmountUah:
+		value = o.AmountUah().String()
+	}
+	return Cursor{Value: value, Id: o.Id}
+}
+
+// Order returns an order holding the cursor's values, so it can be compared
+// with listed orders.
+func (field SortField) Order(c Cursor) (Order, error) {
+	o := Order{Id: c.Id}
+	var err error
+
+	switch field {
+	case SortById:
+		o.Id, err = strconv.Atoi(c.Value)
+	case SortByTimeStamp:
+		o.TimeStamp, err = time.Parse(cursorTimeFormat, c.Value)
+	case SortByType:
+		o.Type = c.Value
+	case SortByAmount:
+		o.Amount, err = decimal.NewFromString(c.Value)
+	case SortByCurrency:
+		o.Currency = c.Value
+	case SortByExchangeRate:
+		o.ExchangeRate, err = decimal.NewFromString(c.Value)
+	case SortByAmountUah:
+		// AmountUah is computed, an amount at rate 1 gives the same value.
+		o.Amount, err = decimal.NewFromString(c.Value)
+		o.ExchangeRate = decimal.NewFromInt(1)
+	}
+	if err != nil {
+		return o, fmt.Errorf("cursor doesn't match sort field %s: %w", field, err)
+	}
+
+	return o, nil
+}
+
+// Encode returns the cursor as an opaque URL-safe token.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(token string) (Cursor, error) {
+	var c Cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil {
+		return c, fmt.Errorf("invalid page cursor %q", token)
+	}
+
+	return c, nil
+}
+
+// OrderQuery describes one page of a filtered and sorted order listing.
+// Limit 0 lists everything on one page. At most one of After and Before is
+// set: After asks for the page following that cursor, Before for the one
+// preceding it.
+type OrderQuery struct {
+	Filter OrderFilter
+	Sort   SortField
+	Desc   bool
+	Limit  int
+	After  *Cursor
+	Before *Cursor
+}
+
+// SortField returns the sort column, id unless another one is set.
+func (q OrderQuery) SortField() SortField {
+	if q.Sort == "" {
+		return SortById
+	}
+	return q.Sort
+}
+
+// Page builds the page from up to Limit+1 rows read in the direction of the
+// query, that is in reverse order when Before is set. The extra row only
+// tells that there is another page in that direction.
+func (q OrderQuery) Page(rows []Order) OrderPage {
+	if q.Limit == 0 {
+		return OrderPage{Orders: rows}
+	}
+
+	more := len(rows) > q.Limit
+	if more {
+		rows = rows[:q.Limit]
+	}
+	if q.Before != nil {
+		slices.Reverse(rows)
+	}
+
+	page := OrderPage{Orders: rows}
+	if len(rows) == 0 {
+		return page
+	}
+
+	first := q.SortField().CursorOf(rows[0]).Encode()
+	last := q.SortField().CursorOf(rows[len(rows)-1]).Encode()
+	if q.Before != nil {
+		page.Next = last
+		if more {
+			page.Prev = first
+		}
+	} else {
+		if more {
+			page.Next = last
+		}
+		if q.After != nil {
+			page.Prev = first
+		}
+	}
+
+	return page
+}
+
+func (q OrderQuery) Validate() error {
+	var errs []error
+	if _, err := ParseSortField(string(q.Sort)); q.Sort != "" && err != nil {
+		errs = append(errs, err)
+	}
+	if q.Limit < 0 {
+		errs = append(errs, fmt.Errorf("limit: must not be negative, got %d", q.Limit))
+	}
+	if q.After != nil && q.Before != nil {
+		errs = append(errs, errors.New("cursor: only one of after and before may be set"))
+	}
+	f := q.Filter
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		errs = append(errs, errors.New("filter: from must be before to"))
+	}
+	if f.MinAmount != nil && f.MaxAmount != nil && f.MinAmount.GreaterThan(*f.MaxAmount) {
+		errs = append(errs, errors.New("filter: min amount is greater than max amount"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// OrderPage is one page of a listing. Next and Prev are cursor tokens of the
+// neighbouring pages, empty when there is none.
+type OrderPage struct {
+	Orders []Order `json:"orders"`
+	Next   string  `json:"next,omitempty"`
+	Prev   string  `json:"prev,omitempty"`
+}
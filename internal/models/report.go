@@ -0,0 +1,301 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DateRange limits a report to orders placed in [From, To). A zero bound
+// leaves that side open.
+type DateRange struct {
+	From time.Time `json:"from,omitzero"`
+	To   time.Time `json:"to,omitzero"`
+}
+
+func (r DateRange) Contains(t time.Time) bool {
+	return (r.From.IsZero() || !t.Before(r.From)) && (r.To.IsZero() || t.Before(r.To))
+}
+
+func (r DateRange) Validate() error {
+	if !r.From.IsZero() && !r.To.IsZero() && !r.From.Before(r.To) {
+		return fmt.Errorf("date range: from %s must be before to %s",
+			r.From.Format(time.DateTime), r.To.Format(time.DateTime))
+	}
+	return nil
+}
+
+// Granularity is the length of the periods of a revenue series.
+type Granularity string
+
+const (
+	ByDay Granularity = "day"
+	// ByWeek periods are ISO weeks, starting on Monday.
+	ByWeek  Granularity = "week"
+	ByMonth Granularity = "month"
+)
+
+var Granularities = []Granularity{ByDay, ByWeek, ByMonth}
+
+func ParseGranularity(raw string) (Granularity, error) {
+	for _, g := range Granularities {
+		if strings.EqualFold(raw, string(g)) {
+			return g, nil
+		}
+	}
+	return "", fmt.Errorf("unknown granularity %q, expected day, week or month", raw)
+}
+
+// Start returns the first day of the period holding t, judged by the calendar
+// of t's zone and written as midnight UTC like the dates read from Postgres.
+func (g Granularity) Start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch g {
+	case ByWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case ByMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// Next returns the start of the period following the one starting at start.
+func (g Granularity) Next(start time.Time) time.Time {
+	switch g {
+	case ByWeek:
+		return start.AddDate(0, 0, 7)
+	case ByMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// span widens the periods from first to last to the bounds of dates that
+// are set.
+func (g Granularity) span(first, last time.Time, dates DateRange) (time.Time, time.Time) {
+	if !dates.From.IsZero() {
+		first = g.Start(dates.From)
+	}
+	if !dates.To.IsZero() {
+		last = g.Start(dates.To.Add(-time.Nanosecond))
+	}
+	return first, last
+}
+
+// RevenuePoint is one period of a revenue series.
+type RevenuePoint struct {
+	Period   time.Time       `json:"period"`
+	Orders   int             `json:"orders"`
+	TotalUah decimal.Decimal `json:"totalUah"`
+	AvgUah   decimal.Decimal `json:"avgUah"`
+	// Change is the difference from the total of the previous period and
+	// ChangePercent the same in percent of it, nil when there is nothing to
+	// compare with.
+	Change        decimal.Decimal  `json:"change"`
+	ChangePercent *decimal.Decimal `json:"changePercent"`
+}
+
+// RevenueSeries completes the totals of the periods that have orders, sorted
+// by Period, into a series without gaps. The series spans dates when its
+// bounds are set and the periods with orders otherwise.
+func RevenueSeries(g Granularity, totals []RevenuePoint, dates DateRange) []RevenuePoint {
+	var first, last time.Time
+	if len(totals) > 0 {
+		first, last = totals[0].Period, totals[len(totals)-1].Period
+	}
+	first, last = g.span(first, last, dates)
+	if first.IsZero() || last.IsZero() {
+		return nil
+	}
+
+	var series []RevenuePoint
+	for period, i := first, 0; !period.After(last); period = g.Next(period) {
+		point := RevenuePoint{Period: period}
+		for ; i < len(totals) && !totals[i].Period.After(period); i++ {
+			if totals[i].Period.Equal(period) {
+				point.Orders, point.TotalUah = totals[i].Orders, totals[i].TotalUah
+			}
+		}
+		if point.Orders > 0 {
+			point.AvgUah = point.TotalUah.DivRound(decimal.NewFromInt(int64(point.Orders)), 2)
+		}
+
+		if len(series) > 0 {
+			previous := series[len(series)-1].TotalUah
+			point.Change = point.TotalUah.Sub(previous)
+			if !previous.IsZero() {
+				percent := point.Change.Mul(decimal.NewFromInt(100)).DivRound(previous, 2)
+				point.ChangePercent = &percent
+			}
+		}
+
+		series = append(series, point)
+	}
+
+	return series
+}
+
+// BreakdownCell sums the orders of one type in one currency. Row and column
+// totals leave out what doesn't add up: a type's total has no Currency and no
+// Amount, a currency's total has no Type.
+type BreakdownCell struct {
+	Type      string          `json:"type,omitempty"`
+	Currency  string          `json:"currency,omitempty"`
+	Orders    int             `json:"orders"`
+	Amount    decimal.Decimal `json:"amount"`
+	AmountUah decimal.Decimal `json:"amountUah"`
+}
+
+func (c BreakdownCell) add(other BreakdownCell) BreakdownCell {
+	c.Orders += other.Orders
+	c.Amount = c.Amount.Add(other.Amount)
+	c.AmountUah = c.AmountUah.Add(other.AmountUah)
+	return c
+}
+
+// BreakdownRow holds the cells of one order type in the order of
+// Breakdown.Currencies. Share is the type's part of the total in UAH, in
+// percent.
+type BreakdownRow struct {
+	Type  string          `json:"type"`
+	Cells []BreakdownCell `json:"cells"`
+	Total BreakdownCell   `json:"total"`
+	Share decimal.Decimal `json:"share"`
+}
+
+// Breakdown pivots orders by type and currency. Rows go from the biggest
+// type in UAH to the smallest.
+type Breakdown struct {
+	Currencies []string        `json:"currencies"`
+	Rows       []BreakdownRow  `json:"rows"`
+	Totals     []BreakdownCell `json:"totals"`
+	Total      BreakdownCell   `json:"total"`
+}
+
+// NewBreakdown builds the pivot from the sums of every type and currency
+// pair that has orders.
+func NewBreakdown(cells []BreakdownCell) Breakdown {
+	var b Breakdown
+
+	for _, cell := range cells {
+		if !slices.Contains(b.Currencies, cell.Currency) {
+			b.Currencies = append(b.Currencies, cell.Currency)
+		}
+	}
+	slices.Sort(b.Currencies)
+
+	columns := make(map[string]int)
+	b.Totals = make([]BreakdownCell, len(b.Currencies))
+	for i, currency := range b.Currencies {
+		columns[currency] = i
+		b.Totals[i].Currency = currency
+	}
+
+	rows := make(map[string]int)
+	for _, cell := range cells {
+		i, ok := rows[cell.Type]
+		if !ok {
+			i = len(b.Rows)
+			rows[cell.Type] = i
+			b.Rows = append(b.Rows, BreakdownRow{
+				Type:  cell.Type,
+				Cells: make([]BreakdownCell, len(b.Currencies)),
+				Total: BreakdownCell{Type: cell.Type},
+			})
+			for j, currency := range b.Currencies {
+				b.Rows[i].Cells[j] = BreakdownCell{Type: cell.Type, Currency: currency}
+			}
+		}
+
+		row, column := &b.Rows[i], columns[cell.Currency]
+		row.Cells[column] = row.Cells[column].add(cell)
+		row.Total = row.Total.add(BreakdownCell{Orders: cell.Orders, AmountUah: cell.AmountUah})
+		b.Totals[column] = b.Totals[column].add(cell)
+		b.Total = b.Total.add(BreakdownCell{Orders: cell.Orders, AmountUah: cell.AmountUah})
+	}
+
+	for i := range b.Rows {
+		if !b.Total.AmountUah.IsZero() {
+			b.Rows[i].Share = b.Rows[i].Total.AmountUah.Mul(decimal.NewFromInt(100)).DivRound(b.Total.AmountUah, 2)
+		}
+	}
+	slices.SortStableFunc(b.Rows, func(x, y BreakdownRow) int {
+		if c := y.Total.AmountUah.Cmp(x.Total.AmountUah); c != 0 {
+			return c
+		}
+		return strings.Compare(x.Type, y.Type)
+	})
+
+	return b
+}
+
+// MonthlyCount is the number of orders of one month.
+type MonthlyCount struct {
+	Month  time.Time `json:"month"`
+	Orders int       `json:"orders"`
+}
+
+// MonthlyDistribution describes how many orders of Type under Threshold in
+// UAH there were per month. StdDev is the population standard deviation.
+type MonthlyDistribution struct {
+	Type      string          `json:"type"`
+	Threshold decimal.Decimal `json:"threshold"`
+	Months    []MonthlyCount  `json:"months"`
+	Mean      float64         `json:"mean"`
+	Median    float64         `json:"median"`
+	StdDev    float64         `json:"stdDev"`
+}
+
+// NewMonthlyDistribution takes the counts of the months that have orders of
+// the type, sorted by Month, and adds the months in between with no orders
+// under the threshold. Like RevenueSeries, it spans dates when their bounds
+// are set. Without any month all the statistics are zero.
+func NewMonthlyDistribution(orderType string, threshold decimal.Decimal, counts []MonthlyCount, dates DateRange) MonthlyDistribution {
+	d := MonthlyDistribution{Type: orderType, Threshold: threshold}
+
+	var first, last time.Time
+	if len(counts) > 0 {
+		first, last = counts[0].Month, counts[len(counts)-1].Month
+	}
+	first, last = ByMonth.span(first, last, dates)
+	if first.IsZero() || last.IsZero() {
+		return d
+	}
+
+	for month, i := first, 0; !month.After(last); month = ByMonth.Next(month) {
+		count := MonthlyCount{Month: month}
+		for ; i < len(counts) && !counts[i].Month.After(month); i++ {
+			if counts[i].Month.Equal(month) {
+				count.Orders = counts[i].Orders
+			}
+		}
+		d.Months = append(d.Months, count)
+	}
+
+	values := make([]float64, len(d.Months))
+	var sum float64
+	for i, count := range d.Months {
+		values[i] = float64(count.Orders)
+		sum += values[i]
+	}
+	d.Mean = sum / float64(len(values))
+
+	var squares float64
+	for _, v := range values {
+		squares += (v - d.Mean) * (v - d.Mean)
+	}
+	d.StdDev = math.Sqrt(squares / float64(len(values)))
+
+	slices.Sort(values)
+	if middle := len(values) / 2; len(values)%2 == 1 {
+		d.Median = values[middle]
+	} else {
+		d.Median = (values[middle-1] + values[middle]) / 2
+	}
+
+	return d
+}
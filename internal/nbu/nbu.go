@@ -0,0 +1,131 @@
+package nbu
+
+import (
+	"bufio"
+	"coursework/internal/models"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/shopspring/decimal"
+)
+
+// DateFormat is how the National Bank of Ukraine writes dates in its exports.
+const DateFormat = "02.01.2006"
+
+// kyiv is the time zone of the exchange dates. The zone database is embedded,
+// so loading it can't fail.
+var kyiv, _ = time.LoadLocation("Europe/Kyiv")
+
+// ParseCSV reads official rates from a CSV exported by the National Bank of
+// Ukraine (bank.gov.ua/NBU_Exchange/exchange_site), for example:
+//
+//	exchangedate,r030,cc,txt,enname,rate,units,rate_per_unit,group,calcdate
+//	01.12.2025,840,USD,Долар США,US Dollar,41.7,1,41.7,1,28.11.2025
+//
+// Columns are found by name, so their order doesn't matter and extra ones
+// are ignored. Files saved with ";" separators and decimal commas are
+// accepted too. Each rate takes effect at midnight of its exchangedate in Kyiv
+// and is the rate of a single unit of the currency.
+func ParseCSV(r io.Reader) ([]models.ExchangeRate, error) {
+	buffered := bufio.NewReader(r)
+
+	firstLine, err := buffered.Peek(buffered.Size())
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, fmt.Errorf("couldn't read rates: %w", err)
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	if header, _, _ := strings.Cut(string(firstLine), "\n"); strings.Count(header, ";") > strings.Count(header, ",") {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read rates header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, required := range []string{"exchangedate", "cc", "rate"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("couldn't read rates: missing %q column", required)
+		}
+	}
+
+	var rates []models.ExchangeRate
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read rates: %w", err)
+		}
+
+		rate, err := parseRecord(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read rates: line %d: %w", line, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+func parseRecord(record []string, columns map[string]int) (models.ExchangeRate, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	number := func(name string) (decimal.Decimal, error) {
+		return decimal.NewFromString(strings.ReplaceAll(field(name), ",", "."))
+	}
+
+	var rate models.ExchangeRate
+	var err error
+
+	rate.EffectiveAt, err = time.ParseInLocation(DateFormat, field("exchangedate"), kyiv)
+	if err != nil {
+		return rate, fmt.Errorf("exchangedate: %w", err)
+	}
+
+	rate.Currency = strings.ToUpper(field("cc"))
+	if len(rate.Currency) != 3 {
+		return rate, fmt.Errorf("cc: %q is not a currency code", rate.Currency)
+	}
+
+	if field("rate_per_unit") != "" {
+		rate.Rate, err = number("rate_per_unit")
+		if err != nil {
+			return rate, fmt.Errorf("rate_per_unit: %w", err)
+		}
+		return rate, nil
+	}
+
+	rate.Rate, err = number("rate")
+	if err != nil {
+		return rate, fmt.Errorf("rate: %w", err)
+	}
+
+	if field("units") != "" {
+		units, err := number("units")
+		if err != nil || !units.IsPositive() {
+			return rate, fmt.Errorf("units: %q is not a positive number", field("units"))
+		}
+		rate.Rate = rate.Rate.Div(units)
+	}
+
+	return rate, nil
+}
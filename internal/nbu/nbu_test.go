@@ -0,0 +1,55 @@
+package nbu
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCSV(t *testing.T) {
+	t.Run("exchange_site export", func(t *testing.T) {
+		input := "exchangedate,r030,cc,txt,enname,rate,units,rate_per_unit,group,calcdate\n" +
+			"01.12.2025,840,USD,Долар США,US Dollar,41.7,1,41.7,1,28.11.2025\n" +
+			"01.12.2025,392,JPY,Єна,Japanese Yen,26.95,100,0.2695,2,28.11.2025\n"
+
+		rates, err := ParseCSV(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(rates) != 2 {
+			t.Fatalf("got %d rates, want 2", len(rates))
+		}
+		if rates[1].Currency != "JPY" || rates[1].Rate.String() != "0.2695" ||
+			!rates[1].EffectiveAt.Equal(time.Date(2025, 11, 30, 22, 0, 0, 0, time.UTC)) {
+			t.Errorf("got %+v", rates[1])
+		}
+	})
+
+	t.Run("semicolons and decimal commas", func(t *testing.T) {
+		input := "\ufeffcc;exchangedate;units;rate\nEUR;02.12.2025;1;48,3012\nJPY;02.12.2025;100;26,90\n"
+
+		rates, err := ParseCSV(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(rates) != 2 || rates[0].Rate.String() != "48.3012" || rates[1].Rate.String() != "0.269" {
+			t.Errorf("got %+v", rates)
+		}
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		_, err := ParseCSV(strings.NewReader("cc,rate\nUSD,41.7\n"))
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("bad date", func(t *testing.T) {
+		_, err := ParseCSV(strings.NewReader("exchangedate,cc,rate\n2025-12-01,USD,41.7\n"))
+		if err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("got %v, want an error on line 2", err)
+		}
+	})
+}
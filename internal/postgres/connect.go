@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ConnectOptions control how Connect waits for the database, which may still
+// be starting, e.g. under docker-compose.
+type ConnectOptions struct {
+	// Timeout limits every ping.
+	Timeout time.Duration
+	// Retries is the number of pings after the first one fails.
+	Retries int
+	// Backoff is the wait before the first retry. It doubles with every
+	// retry, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+var DefaultConnectOptions = ConnectOptions{
+	Timeout:    5 * time.Second,
+	Retries:    5,
+	Backoff:    500 * time.Millisecond,
+	MaxBackoff: 8 * time.Second,
+}
+
+// wait returns how long to wait before the retry-th retry, counted from 1.
+func (o ConnectOptions) wait(retry int) time.Duration {
+	wait := o.Backoff
+	for i := 1; i < retry && wait < o.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, o.MaxBackoff)
+}
+
+// Connect creates a pool and pings the database until it answers, retrying
+// with backoff. A malformed URL fails at once and an empty one is filled in
+// from the PG* environment variables.
+func Connect(ctx context.Context, dbURL string, opts ConnectOptions) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+
+	dbPool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+
+	for retry := 0; ; retry++ {
+		if retry > 0 {
+			wait := opts.wait(retry)
+			slog.Warn("Database is not ready, retrying", "retry", retry, "wait", wait, "err", err)
+
+			select {
+			case <-ctx.Done():
+				dbPool.Close()
+				return nil, fmt.Errorf("error connecting to database: %w", ctx.Err())
+			case <-time.After(wait):
+			}
+		}
+
+		if err = ping(ctx, dbPool, opts.Timeout); err == nil {
+			return dbPool, nil
+		}
+		if retry == opts.Retries {
+			dbPool.Close()
+			return nil, fmt.Errorf("error connecting to database after %d attempts: %w", retry+1, err)
+		}
+	}
+}
+
+func ping(ctx context.Context, dbPool *pgxpool.Pool, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return dbPool.Ping(ctx)
+}
+
+// Health describes the connection pool and the server behind it.
+type Health struct {
+	ServerVersion string        `json:"serverVersion"`
+	Latency       time.Duration `json:"latency"`
+	TotalConns    int32         `json:"totalConns"`
+	IdleConns     int32         `json:"idleConns"`
+	AcquiredConns int32         `json:"acquiredConns"`
+	MaxConns      int32         `json:"maxConns"`
+	// AcquireCount and EmptyAcquireCount count all the connections taken
+	// from the pool and those that had to wait for one.
+	AcquireCount      int64 `json:"acquireCount"`
+	EmptyAcquireCount int64 `json:"emptyAcquireCount"`
+}
+
+// Health asks the server for its version, timing the round trip, and reads
+// the statistics of the pool.
+func (c *DbController) Health(ctx context.Context) (_ Health, err error) {
+	ctx, done := c.operation(ctx, "checking database health", c.timeouts.Query)
+	defer done(&err)
+
+	var h Health
+
+	start := time.Now()
+	if err := c.dbPool.QueryRow(ctx, "SHOW server_version").Scan(&h.ServerVersion); err != nil {
+		return h, fmt.Errorf("error checking database health: %w", err)
+	}
+	h.Latency = time.Since(start)
+
+	stat := c.dbPool.Stat()
+	h.TotalConns = stat.TotalConns()
+	h.IdleConns = stat.IdleConns()
+	h.AcquiredConns = stat.AcquiredConns()
+	h.MaxConns = stat.MaxConns()
+	h.AcquireCount = stat.AcquireCount()
+	h.EmptyAcquireCount = stat.EmptyAcquireCount()
+
+	return h, nil
+}
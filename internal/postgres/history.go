@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"coursework/internal/models"
+	"coursework/internal/storage"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	returningOrder = `
+		RETURNING id, ordered_at, ordertype, amount, currency, exchangerate, deleted_at`
+
+	selectOrderForUpdate = `SELECT id, ordered_at, ordertype, amount, currency, exchangerate, deleted_at
+		FROM orders
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE`
+)
+
+// missingOrder explains why an order wasn't found in the state a change
+// needs: it exists in the other one, which conflicts with the change as
+// reason says, or it doesn't exist at all.
+func missingOrder(ctx context.Context, tx pgx.Tx, orderId int, reason string) error {
+	const query = `SELECT EXISTS (SELECT 1 FROM orders WHERE id = $1)`
+
+	var exists bool
+	if err := tx.QueryRow(ctx, query, orderId).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("order %d %s: %w", orderId, reason, storage.ErrConflict)
+	}
+	return fmt.Errorf("row with id %d: %w", orderId, storage.ErrOrderNotFound)
+}
+
+// scanOrder reads an order with its times in the business time zone.
+func (c *DbController) scanOrder(row pgx.Row) (models.Order, error) {
+	var o models.Order
+	err := row.Scan(&o.Id, &o.TimeStamp, &o.Type, &o.Amount, &o.Currency, &o.ExchangeRate, &o.DeletedAt)
+	c.inLocation(&o)
+	return o, err
+}
+
+func (c *DbController) inLocation(o *models.Order) {
+	if o == nil {
+		return
+	}
+	o.TimeStamp = o.TimeStamp.In(c.location)
+	if o.DeletedAt != nil {
+		deletedAt := o.DeletedAt.In(c.location)
+		o.DeletedAt = &deletedAt
+	}
+}
+
+// recordChange writes a history entry inside the transaction of the change itself.
+func (c *DbController) recordChange(ctx context.Context, tx pgx.Tx, operation string, before, after *models.Order) error {
+	const query = `INSERT INTO order_history (order_id, operation, before, after, changed_by)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	orderId := 0
+	if after != nil {
+		orderId = after.Id
+	} else if before != nil {
+		orderId = before.Id
+	}
+
+	beforeJSON, err := snapshot(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := snapshot(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, query, orderId, operation, beforeJSON, afterJSON, c.actor)
+	if err != nil {
+		return fmt.Errorf("couldn't record order history: %w", err)
+	}
+
+	return nil
+}
+
+func (c *DbController) OrderHistory(ctx context.Context, orderId int) (_ []models.OrderChange, err error) {
+	ctx, done := c.operation(ctx, "getting order history", c.timeouts.Query)
+	defer done(&err)
+
+	const query = `SELECT id, order_id, operation, before, after, changed_at, changed_by
+		FROM order_history
+		WHERE order_id = $1
+		ORDER BY changed_at, id`
+
+	changes, err := c.queryChanges(ctx, query, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting history of order %d: %w", orderId, err)
+	}
+
+	return changes, nil
+}
+
+func (c *DbController) ChangesBetween(ctx context.Context, from, to time.Time) (_ []models.OrderChange, err error) {
+	ctx, done := c.operation(ctx, "getting order changes", c.timeouts.Report)
+	defer done(&err)
+
+	const query = `SELECT id, order_id, operation, before, after, changed_at, changed_by
+		FROM order_history
+		WHERE changed_at >= $1 AND changed_at < $2
+		ORDER BY changed_at, id`
+
+	changes, err := c.queryChanges(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error getting order changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (c *DbController) queryChanges(ctx context.Context, query string, args ...any) ([]models.OrderChange, error) {
+	rows, err := c.dbPool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []models.OrderChange
+	for rows.Next() {
+		var change models.OrderChange
+		var before, after []byte
+
+		err = rows.Scan(&change.Id, &change.OrderId, &change.Operation, &before, &after,
+			&change.ChangedAt, &change.ChangedBy)
+		if err != nil {
+			return nil, err
+		}
+
+		if change.Before, err = fromSnapshot(before); err != nil {
+			return nil, err
+		}
+		if change.After, err = fromSnapshot(after); err != nil {
+			return nil, err
+		}
+		change.ChangedAt = change.ChangedAt.In(c.location)
+		c.inLocation(change.Before)
+		c.inLocation(change.After)
+
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// snapshot encodes an order for a JSONB column, nil stays NULL.
+func snapshot(order *models.Order) ([]byte, error) {
+	if order == nil {
+		return nil, nil
+	}
+	return json.Marshal(order)
+}
+
+func fromSnapshot(data []byte) (*models.Order, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	var order models.Order
+	if err := json.Unmarshal(data, &order); err != nil {
+		return nil, fmt.Errorf("couldn't decode order snapshot: %w", err)
+	}
+	return &order, nil
+}
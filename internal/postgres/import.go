@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"coursework/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (c *DbController) ValidateOrder(ctx context.Context, order models.Order) (err error) {
+	ctx, done := c.operation(ctx, "validating order", c.timeouts.Query)
+	defer done(&err)
+
+	types, err := activeTypes(ctx, c.dbPool)
+	if err != nil {
+		return fmt.Errorf("error validating order: %w", err)
+	}
+	order.Currency = models.NormalizeCurrency(order.Currency)
+	return c.validator.Order(order, types)
+}
+
+// ImportOrders loads the orders and their history with COPY. Ids are taken
+// from the orders sequence up front, so the history rows can refer to them.
+func (c *DbController) ImportOrders(ctx context.Context, orders []models.Order) (_ int, err error) {
+	ctx, done := c.operation(ctx, "importing orders", c.timeouts.Report)
+	defer done(&err)
+
+	const reserveIds = `SELECT nextval(pg_get_serial_sequence('orders', 'id'))
+		FROM generate_series(1, $1)`
+
+	if len(orders) == 0 {
+		return 0, nil
+	}
+	orders = models.NormalizeCurrencies(orders)
+	types, err := activeTypes(ctx, c.dbPool)
+	if err != nil {
+		return 0, fmt.Errorf("error importing orders: %w", err)
+	}
+	if err := c.validator.Import(orders, types); err != nil {
+		return 0, fmt.Errorf("error importing orders: %w", err)
+	}
+
+	err = pgx.BeginFunc(ctx, c.dbPool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, reserveIds, len(orders))
+		if err != nil {
+			return err
+		}
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return fmt.Errorf("couldn't reserve order ids: %w", err)
+		}
+
+		orderRows := make([][]any, 0, len(orders))
+		historyRows := make([][]any, 0, len(orders))
+		for i, order := range orders {
+			order.Id = ids[i]
+			order.TimeStamp = order.TimeStamp.Truncate(time.Second)
+			order.Amount = order.Amount.Round(2)
+			order.ExchangeRate = order.ExchangeRate.Round(6)
+			order.DeletedAt = nil
+
+			orderRows = append(orderRows, []any{order.Id, order.TimeStamp, order.Type, order.Amount, order.Currency, order.ExchangeRate})
+
+			after, err := snapshot(&order)
+			if err != nil {
+				return err
+			}
+			historyRows = append(historyRows, []any{order.Id, models.OperationInsert, after, c.actor})
+		}
+
+		_, err = tx.CopyFrom(ctx, pgx.Identifier{"orders"},
+			[]string{"id", "ordered_at", "ordertype", "amount", "currency", "exchangerate"},
+			pgx.CopyFromRows(orderRows))
+		if err != nil {
+			return fmt.Errorf("couldn't copy orders: %w", err)
+		}
+
+		_, err = tx.CopyFrom(ctx, pgx.Identifier{"order_history"},
+			[]string{"order_id", "operation", "after", "changed_by"},
+			pgx.CopyFromRows(historyRows))
+		if err != nil {
+			return fmt.Errorf("couldn't record order history: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error importing orders: %w", err)
+	}
+
+	return len(orders), nil
+}
@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"coursework/internal/models"
+	"fmt"
+	"strings"
+)
+
+// sortColumns maps the sort fields to their SQL expression and the type their
+// cursor values are cast to.
+var sortColumns = map[models.SortField]struct{ expr, cast string }{
+	models.SortById:           {"id", "integer"},
+	models.SortByTimeStamp:    {"ordered_at", "timestamptz"},
+	models.SortByType:         {"ordertype", "varchar"},
+	models.SortByAmount:       {"amount", "numeric"},
+	models.SortByCurrency:     {"currency", "char(3)"},
+	models.SortByExchangeRate: {"exchangerate", "numeric"},
+	models.SortByAmountUah:    {"ROUND(amount * exchangerate, 2)", "numeric"},
+}
+
+func (c *DbController) ListOrders(ctx context.Context, query models.OrderQuery) (_ models.OrderPage, err error) {
+	ctx, done := c.operation(ctx, "listing orders", c.timeouts.Query)
+	defer done(&err)
+
+	if err := query.Validate(); err != nil {
+		return models.OrderPage{}, fmt.Errorf("error listing orders: %w", err)
+	}
+
+	sql, args := listOrdersQuery(query)
+
+	rows, err := c.dbPool.Query(ctx, sql, args...)
+	if err != nil {
+		return models.OrderPage{}, fmt.Errorf("error listing orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []models.Order
+	for rows.Next() {
+		o, err := c.scanOrder(rows)
+		if err != nil {
+			return models.OrderPage{}, fmt.Errorf("error scanning row: %w", err)
+		}
+		orders = append(orders, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.OrderPage{}, fmt.Errorf("error listing orders: %w", err)
+	}
+
+	return query.Page(orders), nil
+}
+
+// listOrdersQuery builds the SELECT for one page. Pages before a cursor are
+// read in reverse order, models.OrderQuery.Page puts them back.
+func listOrdersQuery(query models.OrderQuery) (string, []any) {
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{"deleted_at IS NULL"}
+
+	f := query.Filter
+	if !f.From.IsZero() {
+		where = append(where, "ordered_at >= "+arg(f.From))
+	}
+	if !f.To.IsZero() {
+		where = append(where, "ordered_at < "+arg(f.To))
+	}
+	if f.Type != "" {
+		where = append(where, "ordertype = "+arg(f.Type))
+	}
+	if f.Currency != "" {
+		where = append(where, "currency = "+arg(strings.ToUpper(f.Currency)))
+	}
+
+	amount := "amount"
+	if f.InUah {
+		amount = "ROUND(amount * exchangerate, 2)"
+	}
+	if f.MinAmount != nil {
+		where = append(where, amount+" >= "+arg(*f.MinAmount))
+	}
+	if f.MaxAmount != nil {
+		where = append(where, amount+" <= "+arg(*f.MaxAmount))
+	}
+
+	column := sortColumns[query.SortField()]
+	desc := query.Desc != (query.Before != nil)
+
+	cursor := query.After
+	if query.Before != nil {
+		cursor = query.Before
+	}
+	if cursor != nil {
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s::%s, %s::integer)",
+			column.expr, op, arg(cursor.Value), column.cast, arg(cursor.Id)))
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+
+	sql := fmt.Sprintf(`SELECT id, ordered_at, ordertype, amount, currency, exchangerate, deleted_at
+		FROM orders
+		WHERE %s
+		ORDER BY %s %s, id %s`, strings.Join(where, " AND "), column.expr, direction, direction)
+
+	if query.Limit > 0 {
+		sql += "\n\t\tLIMIT " + arg(query.Limit+1)
+	}
+
+	return sql, args
+}
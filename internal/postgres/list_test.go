@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"coursework/internal/models"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestListOrdersQuery(t *testing.T) {
+	minAmount := decimal.NewFromInt(100)
+	from := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("filter and cursor", func(t *testing.T) {
+		query := models.OrderQuery{
+			Filter: models.OrderFilter{From: from, Currency: "usd", MinAmount: &minAmount, InUah: true},
+			Sort:   models.SortByTimeStamp,
+			Desc:   true,
+			Limit:  20,
+			After:  &models.Cursor{Value: "2025-12-05 10:00:00+02:00", Id: 7},
+		}
+
+		sql, args := listOrdersQuery(query)
+
+		for _, want := range []string{
+			"ordered_at >= $1",
+			"currency = $2",
+			"ROUND(amount * exchangerate, 2) >= $3",
+			"(ordered_at, id) < ($4::timestamptz, $5::integer)",
+			"ORDER BY ordered_at DESC, id DESC",
+			"LIMIT $6",
+		} {
+			if !strings.Contains(sql, want) {
+				t.Errorf("got %s, want it to contain %s", sql, want)
+			}
+		}
+
+		wantArgs := []any{from, "USD", minAmount, "2025-12-05 10:00:00+02:00", 7, 21}
+		if !reflect.DeepEqual(args, wantArgs) {
+			t.Errorf("got args %v, want %v", args, wantArgs)
+		}
+	})
+
+	t.Run("page before a cursor is read backwards", func(t *testing.T) {
+		query := models.OrderQuery{Limit: 5, Before: &models.Cursor{Value: "9", Id: 9}}
+
+		sql, _ := listOrdersQuery(query)
+
+		for _, want := range []string{"(id, id) < ($1::integer, $2::integer)", "ORDER BY id DESC, id DESC"} {
+			if !strings.Contains(sql, want) {
+				t.Errorf("got %s, want it to contain %s", sql, want)
+			}
+		}
+	})
+}
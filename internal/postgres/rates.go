@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"coursework/internal/models"
+	"coursework/internal/storage"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+func (c *DbController) AddRates(ctx context.Context, rates []models.ExchangeRate) (_ int, err error) {
+	ctx, done := c.operation(ctx, "adding exchange rates", c.timeouts.Report)
+	defer done(&err)
+
+	const query = `INSERT INTO exchange_rates (currency, effective_at, rate)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (currency, effective_at) DO UPDATE SET rate = EXCLUDED.rate`
+
+	batch := &pgx.Batch{}
+	for _, rate := range rates {
+		batch.Queue(query, rate.Currency, rate.EffectiveAt, rate.Rate)
+	}
+
+	err = pgx.BeginFunc(ctx, c.dbPool, func(tx pgx.Tx) error {
+		return tx.SendBatch(ctx, batch).Close()
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error adding exchange rates: %w", err)
+	}
+
+	return len(rates), nil
+}
+
+func (c *DbController) RateAt(ctx context.Context, currency string, at time.Time) (_ decimal.Decimal, err error) {
+	ctx, done := c.operation(ctx, "getting exchange rate", c.timeouts.Query)
+	defer done(&err)
+
+	rate, err := officialRate(ctx, c.dbPool, currency, at)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("error getting %s rate at %s: %w", currency, at.Format(time.DateTime), err)
+	}
+
+	return rate, nil
+}
+
+// officialRate returns the rate of currency in effect at at.
+func officialRate(ctx context.Context, q querier, currency string, at time.Time) (decimal.Decimal, error) {
+	const query = `SELECT rate FROM exchange_rates
+		WHERE currency = $1 AND effective_at <= $2
+		ORDER BY effective_at DESC
+		LIMIT 1`
+
+	var rate decimal.Decimal
+	err := q.QueryRow(ctx, query, currency, at).Scan(&rate)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return decimal.Zero, storage.ErrRateNotFound
+	}
+	return rate, err
+}
+
+func (c *DbController) OrdersWhenOfficialRateChanged(ctx context.Context, dates models.DateRange) (_ []models.Order, err error) {
+	ctx, done := c.operation(ctx, "getting orders when official rate changed", c.timeouts.Report)
+	defer done(&err)
+
+	query := `
+		WITH changes AS (
+			SELECT currency, (effective_at AT TIME ZONE $3)::date AS changed_on
+			FROM (SELECT currency, effective_at, rate,
+			             LAG(rate) OVER (PARTITION BY currency ORDER BY effective_at) AS previous_rate
+			      FROM exchange_rates) r
+			WHERE previous_rate IS NOT NULL AND rate <> previous_rate
+		)
+		SELECT id, ordered_at, ordertype, amount, currency, exchangerate
+		FROM orders o
+		WHERE deleted_at IS NULL AND ` + inDateRange(1) + `
+		  AND EXISTS (SELECT 1 FROM changes ch
+		              WHERE ch.currency = o.currency AND ch.changed_on = (o.ordered_at AT TIME ZONE $3)::date)
+		ORDER BY (ordered_at AT TIME ZONE $3)::date, currency`
+
+	from, to := dateRangeArgs(dates)
+	rows, err := c.dbPool.Query(ctx, query, from, to, c.location.String())
+	if err != nil {
+		return nil, fmt.Errorf("error getting orders when official rate changed: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []models.Order
+	for rows.Next() {
+		o := models.Order{}
+		err = rows.Scan(&o.Id, &o.TimeStamp, &o.Type, &o.Amount, &o.Currency, &o.ExchangeRate)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		o.TimeStamp = o.TimeStamp.In(c.location)
+		orders = append(orders, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error getting orders when official rate changed: %w", err)
+	}
+
+	return orders, nil
+}
@@ -3,49 +3,125 @@ package postgres
 import (
 	"context"
 	"coursework/internal/models"
+	"coursework/internal/storage"
+	"coursework/internal/validation"
+	"errors"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
 )
 
+var _ storage.Store = (*DbController)(nil)
+
 type DbController struct {
-	ctx    context.Context
 	dbPool *pgxpool.Pool
+	// actor is recorded in order_history as the author of every change.
+	actor string
+	// location is the business time zone: orders are read in it and grouped
+	// by its days.
+	location  *time.Location
+	timeouts  Timeouts
+	validator *validation.Validator
+}
+
+// Timeouts limit how long a call waits for the database. Reports, imports
+// and purges scan many rows and get the longer Report timeout.
+type Timeouts struct {
+	Query  time.Duration
+	Report time.Duration
 }
 
-func NewDbController(dbURL string) *DbController {
-	ctx := context.Background()
-	dbPool, err := pgxpool.New(ctx, dbURL)
+var DefaultTimeouts = Timeouts{Query: 10 * time.Second, Report: time.Minute}
+
+// NewDbController connects to the database, see Connect.
+func NewDbController(ctx context.Context, dbURL string, opts ConnectOptions) (*DbController, error) {
+	dbPool, err := Connect(ctx, dbURL, opts)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Unable to create connection pool: %v\n", err)
-		os.Exit(1)
+		return nil, err
+	}
+
+	return &DbController{
+		dbPool:    dbPool,
+		actor:     "system",
+		location:  time.UTC,
+		timeouts:  DefaultTimeouts,
+		validator: validation.New(validation.DefaultRules()),
+	}, nil
+}
+
+// SetValidator sets the rules new and changed orders are checked against.
+func (c *DbController) SetValidator(validator *validation.Validator) {
+	c.validator = validator
+}
+
+// SetTimeouts sets the timeouts of subsequent calls.
+func (c *DbController) SetTimeouts(timeouts Timeouts) {
+	c.timeouts = timeouts
+}
+
+// conflictCodes are the SQLSTATEs of changes that lost a race with another
+// transaction: unique_violation, serialization_failure and deadlock_detected.
+var conflictCodes = map[string]bool{"23505": true, "40001": true, "40P01": true}
+
+// operation derives the context of one call from ctx, limited by timeout.
+// The returned function releases it and, if the call failed because the
+// timeout ran out, replaces *err with a storage.TimeoutError, or marks it as
+// a storage.ErrConflict if it lost a race with another transaction.
+func (c *DbController) operation(ctx context.Context, op string, timeout time.Duration) (context.Context, func(*error)) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func(err *error) {
+		defer cancel()
+		var pgErr *pgconn.PgError
+		switch {
+		case *err == nil:
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			*err = &storage.TimeoutError{Op: op, Timeout: timeout, Err: *err}
+		case errors.As(*err, &pgErr) && conflictCodes[pgErr.Code]:
+			*err = fmt.Errorf("%w: %w", storage.ErrConflict, *err)
+		}
 	}
+}
 
-	return &DbController{ctx: ctx, dbPool: dbPool}
+// SetActor sets the user recorded in the history of subsequent changes.
+func (c *DbController) SetActor(actor string) {
+	c.actor = actor
+}
+
+// SetLocation sets the business time zone.
+func (c *DbController) SetLocation(location *time.Location) {
+	c.location = location
+}
+
+func (c *DbController) Location() *time.Location {
+	return c.location
 }
 
 func (c *DbController) Close() {
 	c.dbPool.Close()
 }
 
-func (c *DbController) SelectAllOrders(limit int) ([]models.Order, error) {
+func (c *DbController) SelectAllOrders(ctx context.Context, limit int) (_ []models.Order, err error) {
+	ctx, done := c.operation(ctx, "selecting all orders", c.timeouts.Query)
+	defer done(&err)
+
 	const (
-		query          = "SELECT id, (orderdate + ordertime) as orderTimeStamp, ordertype, amount, currency, exchangerate FROM orders"
-		queryWithLimit = `SELECT id, (orderdate + ordertime) as orderTimeStamp, ordertype, amount, currency, exchangerate 
+		query          = "SELECT id, ordered_at, ordertype, amount, currency, exchangerate FROM orders WHERE deleted_at IS NULL"
+		queryWithLimit = `SELECT id, ordered_at, ordertype, amount, currency, exchangerate 
 		 FROM orders
+		 WHERE deleted_at IS NULL
 		 LIMIT $1`
 	)
 
 	var rows pgx.Rows
-	var err error
 
 	if limit == 0 {
-		rows, err = c.dbPool.Query(c.ctx, query)
+		rows, err = c.dbPool.Query(ctx, query)
 	} else {
-		rows, err = c.dbPool.Query(c.ctx, queryWithLimit, limit)
+		rows, err = c.dbPool.Query(ctx, queryWithLimit, limit)
 	}
 
 	if err != nil {
@@ -60,6 +136,7 @@ func (c *DbController) SelectAllOrders(limit int) ([]models.Order, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
+		o.TimeStamp = o.TimeStamp.In(c.location)
 		orders = append(orders, o)
 	}
 
@@ -70,67 +147,296 @@ func (c *DbController) SelectAllOrders(limit int) ([]models.Order, error) {
 	return orders, nil
 }
 
-func (c *DbController) AddNewOrder(orderDate time.Time, orderType string, amount float64, currency string, exchangerate float64) error {
-	const query = `INSERT INTO orders (orderDate, orderTime, orderType, amount, currency, exchangerate)
-		 VALUES (($1::timestamp)::date, ($1::timestamp)::time, $2, $3, $4, $5)`
+func (c *DbController) AddNewOrder(ctx context.Context, orderDate time.Time, orderType string, amount decimal.Decimal, currency string, exchangerate decimal.Decimal) (err error) {
+	ctx, done := c.operation(ctx, "adding new order", c.timeouts.Query)
+	defer done(&err)
 
-	tag, err := c.dbPool.Exec(c.ctx, query, orderDate, orderType, amount, currency, exchangerate)
+	const query = `INSERT INTO orders (ordered_at, orderType, amount, currency, exchangerate)
+		VALUES ($1, $2, $3, $4, $5)`
 
+	currency = models.NormalizeCurrency(currency)
+	order := models.Order{TimeStamp: orderDate, Type: orderType, Amount: amount, Currency: currency, ExchangeRate: exchangerate}
+	types, err := activeTypes(ctx, c.dbPool)
 	if err != nil {
 		return fmt.Errorf("error adding new order: %w", err)
 	}
+	if err := c.validator.NewOrder(order, types); err != nil {
+		return fmt.Errorf("error adding new order: %w", err)
+	}
 
-	if tag.RowsAffected() == 0 {
-		return fmt.Errorf("error adding new order: no rows inserted")
+	err = pgx.BeginFunc(ctx, c.dbPool, func(tx pgx.Tx) error {
+		// without an explicit rate the official one in effect at orderDate is
+		// used, and checked like an entered one
+		if exchangerate.IsZero() && currency == "UAH" {
+			exchangerate = decimal.NewFromInt(1)
+		}
+		if exchangerate.IsZero() {
+			rate, err := officialRate(ctx, tx, currency, orderDate)
+			if errors.Is(err, storage.ErrRateNotFound) {
+				return fmt.Errorf("no %s rate at %s: %w", currency, orderDate.Format(time.DateTime), err)
+			}
+			if err != nil {
+				return err
+			}
+			if err = c.validator.Rate(currency, rate); err != nil {
+				return fmt.Errorf("official rate: %w", err)
+			}
+			exchangerate = rate
+		}
+
+		after, err := c.scanOrder(tx.QueryRow(ctx, query+returningOrder, orderDate, orderType, amount, currency, exchangerate))
+		if err != nil {
+			return err
+		}
+
+		return c.recordChange(ctx, tx, models.OperationInsert, nil, &after)
+	})
+	if err != nil {
+		return fmt.Errorf("error adding new order: %w", err)
 	}
 
 	return nil
 }
 
-func (c *DbController) UpdateOrder(orderId int, orderType string) error {
-	const query = `UPDATE orders 
-					SET ordertype = $1
-					WHERE id = $2`
+func (c *DbController) GetOrder(ctx context.Context, orderId int) (_ models.Order, err error) {
+	ctx, done := c.operation(ctx, "getting order", c.timeouts.Query)
+	defer done(&err)
+
+	const query = `SELECT id, ordered_at, ordertype, amount, currency, exchangerate
+		FROM orders
+		WHERE id = $1 AND deleted_at IS NULL`
 
-	report, err := c.dbPool.Exec(c.ctx, query, orderType, orderId)
+	var o models.Order
+	err = c.dbPool.QueryRow(ctx, query, orderId).
+		Scan(&o.Id, &o.TimeStamp, &o.Type, &o.Amount, &o.Currency, &o.ExchangeRate)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return o, fmt.Errorf("error getting order: row with id %d: %w", orderId, storage.ErrOrderNotFound)
+	}
 	if err != nil {
-		return fmt.Errorf("error updating order: %w", err)
+		return o, fmt.Errorf("error getting order: %w", err)
 	}
+	o.TimeStamp = o.TimeStamp.In(c.location)
 
-	if report.RowsAffected() == 0 {
-		return fmt.Errorf("error updating order: row with id %d is not found", orderId)
+	return o, nil
+}
+
+func (c *DbController) UpdateOrder(ctx context.Context, orderId int, orderType string) error {
+	return c.PatchOrder(ctx, orderId, models.OrderPatch{Type: &orderType})
+}
+
+func (c *DbController) PatchOrder(ctx context.Context, orderId int, patch models.OrderPatch) (err error) {
+	ctx, done := c.operation(ctx, "updating order", c.timeouts.Query)
+	defer done(&err)
+
+	patch = patch.Normalized()
+
+	const query = `UPDATE orders
+					SET ordered_at = COALESCE($2::timestamptz, ordered_at),
+						ordertype = COALESCE($3::varchar, ordertype),
+						amount = COALESCE($4::numeric, amount),
+						currency = COALESCE($5::char(3), currency),
+						exchangerate = COALESCE($6::numeric, exchangerate)
+					WHERE id = $1 AND deleted_at IS NULL`
+
+	err = pgx.BeginFunc(ctx, c.dbPool, func(tx pgx.Tx) error {
+		before, err := c.scanOrder(tx.QueryRow(ctx, selectOrderForUpdate, orderId))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return missingOrder(ctx, tx, orderId, "is deleted")
+		}
+		if err != nil {
+			return err
+		}
+		types, err := activeTypes(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if err = c.validator.Patch(before, patch, types); err != nil {
+			return err
+		}
+
+		after, err := c.scanOrder(tx.QueryRow(ctx, query+returningOrder, orderId,
+			patch.TimeStamp, patch.Type, patch.Amount, patch.Currency, patch.ExchangeRate))
+		if err != nil {
+			return err
+		}
+
+		return c.recordChange(ctx, tx, models.OperationUpdate, &before, &after)
+	})
+	if err != nil {
+		return fmt.Errorf("error updating order: %w", err)
 	}
 
 	return nil
 }
 
-func (c *DbController) DeleteOrder(orderId int) error {
-	const query = `DELETE FROM orders WHERE id = $1`
+// DeleteOrder soft-deletes the order: it stays in the table, hidden from every
+// query, until it is restored or purged.
+func (c *DbController) DeleteOrder(ctx context.Context, orderId int) (err error) {
+	ctx, done := c.operation(ctx, "deleting order", c.timeouts.Query)
+	defer done(&err)
 
-	report, err := c.dbPool.Exec(c.ctx, query, orderId)
+	const query = `UPDATE orders SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL`
+
+	err = pgx.BeginFunc(ctx, c.dbPool, func(tx pgx.Tx) error {
+		before, err := c.scanOrder(tx.QueryRow(ctx, selectOrderForUpdate, orderId))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return missingOrder(ctx, tx, orderId, "is already deleted")
+		}
+		if err != nil {
+			return err
+		}
+
+		after, err := c.scanOrder(tx.QueryRow(ctx, query+returningOrder, orderId))
+		if err != nil {
+			return err
+		}
+
+		return c.recordChange(ctx, tx, models.OperationDelete, &before, &after)
+	})
 	if err != nil {
 		return fmt.Errorf("error deleting order: %w", err)
 	}
 
-	if report.RowsAffected() == 0 {
-		return fmt.Errorf("error deleting order: row with id %d is not found", orderId)
+	return nil
+}
+
+func (c *DbController) DeletedOrders(ctx context.Context) (_ []models.Order, err error) {
+	ctx, done := c.operation(ctx, "selecting deleted orders", c.timeouts.Query)
+	defer done(&err)
+
+	const query = `SELECT id, ordered_at, ordertype, amount, currency, exchangerate, deleted_at
+		FROM orders
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id`
+
+	rows, err := c.dbPool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting deleted orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []models.Order
+	for rows.Next() {
+		o, err := c.scanOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		orders = append(orders, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error selecting deleted orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+func (c *DbController) RestoreOrder(ctx context.Context, orderId int) (err error) {
+	ctx, done := c.operation(ctx, "restoring order", c.timeouts.Query)
+	defer done(&err)
+
+	const (
+		selectDeleted = `SELECT id, ordered_at, ordertype, amount, currency, exchangerate, deleted_at
+			FROM orders
+			WHERE id = $1 AND deleted_at IS NOT NULL
+			FOR UPDATE`
+		query = `UPDATE orders SET deleted_at = NULL WHERE id = $1`
+	)
+
+	err = pgx.BeginFunc(ctx, c.dbPool, func(tx pgx.Tx) error {
+		before, err := c.scanOrder(tx.QueryRow(ctx, selectDeleted, orderId))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return missingOrder(ctx, tx, orderId, "is not deleted")
+		}
+		if err != nil {
+			return err
+		}
+
+		after, err := c.scanOrder(tx.QueryRow(ctx, query+returningOrder, orderId))
+		if err != nil {
+			return err
+		}
+
+		return c.recordChange(ctx, tx, models.OperationRestore, &before, &after)
+	})
+	if err != nil {
+		return fmt.Errorf("error restoring order: %w", err)
 	}
 
 	return nil
 }
 
-func (c *DbController) DatesWithBiggestOrders(limit int) ([]models.BiggestOrders, error) {
-	const query = `
-		SELECT orderdate, SUM(amount*exchangerate) as total_uah FROM orders
-		GROUP BY orderdate
-		ORDER BY total_uah DESC 
+func (c *DbController) PurgeDeletedOrders(ctx context.Context, deletedBefore time.Time) (_ int, err error) {
+	ctx, done := c.operation(ctx, "purging deleted orders", c.timeouts.Report)
+	defer done(&err)
+
+	const query = `DELETE FROM orders WHERE deleted_at < $1`
+
+	var purged int
+	err = pgx.BeginFunc(ctx, c.dbPool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query+returningOrder, deletedBefore)
+		if err != nil {
+			return err
+		}
+
+		orders, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Order, error) {
+			return c.scanOrder(row)
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, order := range orders {
+			if err = c.recordChange(ctx, tx, models.OperationPurge, &order, nil); err != nil {
+				return err
+			}
+		}
+
+		purged = len(orders)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error purging deleted orders: %w", err)
+	}
+
+	return purged, nil
+}
+
+// inDateRange limits a query to orders placed within the range bound to the
+// parameters $n and $n+1, see dateRangeArgs.
+func inDateRange(n int) string {
+	return fmt.Sprintf(`ordered_at >= COALESCE($%d::timestamptz, '-infinity')
+		  AND ordered_at < COALESCE($%d::timestamptz, 'infinity')`, n, n+1)
+}
+
+// dateRangeArgs turns open bounds into NULLs.
+func dateRangeArgs(dates models.DateRange) (from, to any) {
+	if !dates.From.IsZero() {
+		from = dates.From
+	}
+	if !dates.To.IsZero() {
+		to = dates.To
+	}
+	return from, to
+}
+
+func (c *DbController) DatesWithBiggestOrders(ctx context.Context, limit int, dates models.DateRange) (_ []models.BiggestOrders, err error) {
+	ctx, done := c.operation(ctx, "getting dates with biggest orders", c.timeouts.Report)
+	defer done(&err)
+
+	query := `
+		SELECT (ordered_at AT TIME ZONE $4)::date AS order_date, SUM(ROUND(amount*exchangerate, 2)) as total_uah FROM orders
+		WHERE deleted_at IS NULL AND ` + inDateRange(2) + `
+		GROUP BY 1
+		ORDER BY total_uah DESC, order_date
 		LIMIT $1`
 
 	if limit == 0 {
 		return nil, nil
 	}
 
-	rows, err := c.dbPool.Query(c.ctx, query, limit)
+	from, to := dateRangeArgs(dates)
+	rows, err := c.dbPool.Query(ctx, query, limit, from, to, c.location.String())
 	if err != nil {
 		return nil, fmt.Errorf("error getting dates with biggest orders: %w", err)
 	}
@@ -151,13 +457,23 @@ func (c *DbController) DatesWithBiggestOrders(limit int) ([]models.BiggestOrders
 	return orders, nil
 }
 
-func (c *DbController) TypeOfSmallestOrders(limit int) ([]string, error) {
-	const query = `SELECT DISTINCT ordertype FROM
+func (c *DbController) TypeOfSmallestOrders(ctx context.Context, limit int, dates models.DateRange) (_ []string, err error) {
+	ctx, done := c.operation(ctx, "getting type of smallest orders", c.timeouts.Report)
+	defer done(&err)
+
+	if limit < 0 {
+		return nil, fmt.Errorf("error getting type of smallest orders: %w", storage.Invalid("limit", "must not be negative, got %d", limit))
+	}
+
+	query := `SELECT DISTINCT ordertype FROM
                               (SELECT ordertype, amount FROM orders
+                              WHERE deleted_at IS NULL AND ` + inDateRange(2) + `
                               ORDER BY amount ASC
-                              LIMIT $1)`
+                              LIMIT $1)
+                              ORDER BY ordertype`
 
-	rows, err := c.dbPool.Query(c.ctx, query, limit)
+	from, to := dateRangeArgs(dates)
+	rows, err := c.dbPool.Query(ctx, query, limit, from, to)
 	if err != nil {
 		return nil, fmt.Errorf("error getting type of smallest orders: %w", err)
 	}
@@ -172,21 +488,29 @@ func (c *DbController) TypeOfSmallestOrders(limit int) ([]string, error) {
 		}
 		orderTypes = append(orderTypes, res)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error getting type of smallest orders: %w", err)
+	}
 
 	return orderTypes, nil
 }
 
-func (c *DbController) OrdersWhenRateChanged() ([]models.Order, error) {
-	const query = `
-		SELECT id, (orderdate + ordertime) as orderTimeStamp, ordertype, amount, currency, exchangerate 
+func (c *DbController) OrdersWhenRateChanged(ctx context.Context, dates models.DateRange) (_ []models.Order, err error) {
+	ctx, done := c.operation(ctx, "getting orders when rate changed", c.timeouts.Report)
+	defer done(&err)
+
+	query := `
+		SELECT id, ordered_at, ordertype, amount, currency, exchangerate 
 		FROM orders
-		WHERE (orderdate, currency) IN (
-		SELECT orderdate, currency FROM orders
-		GROUP BY orderdate, currency
+		WHERE deleted_at IS NULL AND ` + inDateRange(1) + ` AND ((ordered_at AT TIME ZONE $3)::date, currency) IN (
+		SELECT (ordered_at AT TIME ZONE $3)::date, currency FROM orders
+		WHERE deleted_at IS NULL AND ` + inDateRange(1) + `
+		GROUP BY 1, 2
 		HAVING COUNT(DISTINCT exchangerate) > 1)
-		ORDER BY orderdate, currency`
+		ORDER BY (ordered_at AT TIME ZONE $3)::date, currency`
 
-	rows, err := c.dbPool.Query(c.ctx, query)
+	from, to := dateRangeArgs(dates)
+	rows, err := c.dbPool.Query(ctx, query, from, to, c.location.String())
 	if err != nil {
 		return nil, fmt.Errorf("error getting orders when rate changed: %w", err)
 	}
@@ -199,6 +523,7 @@ func (c *DbController) OrdersWhenRateChanged() ([]models.Order, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
+		o.TimeStamp = o.TimeStamp.In(c.location)
 		orders = append(orders, o)
 	}
 
@@ -209,62 +534,143 @@ func (c *DbController) OrdersWhenRateChanged() ([]models.Order, error) {
 	return orders, nil
 }
 
-func (c *DbController) GetAvgNumOfOrdersLessThan(orderType string, lessThen float64) (float64, error) {
-	const query = `SELECT (SELECT COUNT(*) FROM orders
-				WHERE ordertype LIKE $1
-				  AND (amount*exchangerate) < $2) * 1.0
-		/
-		(SELECT COUNT (DISTINCT to_char(orderdate, 'YYYY-MM'))
-		FROM orders) AS  avg_less_then`
+func (c *DbController) MonthlyDistribution(ctx context.Context, orderType string, lessThen decimal.Decimal, dates models.DateRange) (_ models.MonthlyDistribution, err error) {
+	ctx, done := c.operation(ctx, "getting monthly distribution", c.timeouts.Report)
+	defer done(&err)
 
-	row := c.dbPool.QueryRow(c.ctx, query, orderType, lessThen)
+	query := `
+		SELECT date_trunc('month', ordered_at AT TIME ZONE $5)::date AS month,
+		       COUNT(*) FILTER (WHERE ROUND(amount*exchangerate, 2) < $2)
+		FROM orders
+		WHERE deleted_at IS NULL AND ordertype = $1 AND ` + inDateRange(3) + `
+		GROUP BY 1
+		ORDER BY 1`
 
-	var avgNum float64
-	err := row.Scan(&avgNum)
+	from, to := dateRangeArgs(dates)
+	rows, err := c.dbPool.Query(ctx, query, orderType, lessThen, from, to, c.location.String())
 	if err != nil {
-		return 0, fmt.Errorf("couldn't show avg num of orders less then %f: %w", lessThen, err)
+		return models.MonthlyDistribution{}, fmt.Errorf("couldn't get monthly distribution of orders less then %s: %w", lessThen, err)
 	}
 
-	return avgNum, nil
+	counts, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.MonthlyCount])
+	if err != nil {
+		return models.MonthlyDistribution{}, fmt.Errorf("couldn't get monthly distribution of orders less then %s: %w", lessThen, err)
+	}
+
+	return models.NewMonthlyDistribution(orderType, lessThen, counts, dates), nil
 }
 
-func (c *DbController) GetTableForPeriods() ([]models.PeriodStats, error) {
-	const query = `
+func (c *DbController) GetTableForPeriods(ctx context.Context, buckets models.Buckets, dates models.DateRange) (_ []models.PeriodStats, err error) {
+	ctx, done := c.operation(ctx, "getting table for periods", c.timeouts.Report)
+	defer done(&err)
+
+	// the buckets are put together from the counts per minute of the day in
+	// the buckets' zone, the business one unless set
+	query := `
 	SELECT
-		CASE
-			WHEN ordertime >= '00:00:00' AND ordertime < '08:00:00' THEN '00:00 - 08:00'
-			WHEN ordertime >= '08:00:00' AND ordertime < '16:00:00' THEN '08:00 - 16:00'
-			ELSE '16:00 - 23:59'
-		END AS time_period,
+		(EXTRACT(HOUR FROM local_time) * 60 + EXTRACT(MINUTE FROM local_time))::integer AS minute,
 	
 		COUNT(*) AS total_sales,
 	
-		COUNT(*) FILTER (WHERE(amount*exchangerate) > 1000) AS big_sales,
-	
-		COUNT(*) FILTER (WHERE(amount*exchangerate) <= 1000) AS small_sales
-	FROM orders
+		COUNT(*) FILTER (WHERE amount_uah > $2) AS big_sales
+	FROM (SELECT ordered_at AT TIME ZONE $1 AS local_time,
+	             ROUND(amount*exchangerate, 2) AS amount_uah
+	      FROM orders
+	      WHERE deleted_at IS NULL AND ` + inDateRange(3) + `) o
 	GROUP BY
-		1
-	ORDER BY time_period`
+		1`
+
+	if err := buckets.Validate(); err != nil {
+		return nil, fmt.Errorf("error getting table for stats: %w", err)
+	}
 
-	rows, err := c.dbPool.Query(c.ctx, query)
+	if buckets.Location == nil {
+		buckets.Location = c.location
+	}
+
+	from, to := dateRangeArgs(dates)
+	rows, err := c.dbPool.Query(ctx, query, buckets.Location.String(), buckets.BigSale, from, to)
 	if err != nil {
 		return nil, fmt.Errorf("error getting table for stats: %w", err)
 	}
+
+	counts, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.MinuteCount])
+	if err != nil {
+		return nil, fmt.Errorf("error getting table for stats: %w", err)
+	}
+
+	return buckets.Stats(counts), nil
+}
+
+func (c *DbController) RevenueSeries(ctx context.Context, granularity models.Granularity, dates models.DateRange) (_ []models.RevenuePoint, err error) {
+	ctx, done := c.operation(ctx, "getting revenue series", c.timeouts.Report)
+	defer done(&err)
+
+	query := `
+		SELECT date_trunc($1, ordered_at AT TIME ZONE $4)::date AS period,
+		       COUNT(*),
+		       SUM(ROUND(amount*exchangerate, 2))
+		FROM orders
+		WHERE deleted_at IS NULL AND ` + inDateRange(2) + `
+		GROUP BY 1
+		ORDER BY 1`
+
+	if _, err := models.ParseGranularity(string(granularity)); err != nil {
+		return nil, fmt.Errorf("error getting revenue series: %w", err)
+	}
+
+	from, to := dateRangeArgs(dates)
+	rows, err := c.dbPool.Query(ctx, query, string(granularity), from, to, c.location.String())
+	if err != nil {
+		return nil, fmt.Errorf("error getting revenue series: %w", err)
+	}
 	defer rows.Close()
 
-	var stats []models.PeriodStats
-	for i := 0; rows.Next(); i++ {
-		stats = append(stats, models.PeriodStats{})
-		err = rows.Scan(&stats[i].TimePeriod, &stats[i].TotalSales, &stats[i].BigSales, &stats[i].SmallSales)
-		if err != nil {
-			return nil, fmt.Errorf("error getting table for stats: %w", err)
+	var totals []models.RevenuePoint
+	for rows.Next() {
+		var point models.RevenuePoint
+		if err = rows.Scan(&point.Period, &point.Orders, &point.TotalUah); err != nil {
+			return nil, fmt.Errorf("error getting revenue series: %w", err)
 		}
+		totals = append(totals, point)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error getting table for stats: %w", err)
+		return nil, fmt.Errorf("error getting revenue series: %w", err)
+	}
+
+	return models.RevenueSeries(granularity, totals, dates), nil
+}
+
+func (c *DbController) Breakdown(ctx context.Context, dates models.DateRange) (_ models.Breakdown, err error) {
+	ctx, done := c.operation(ctx, "getting breakdown", c.timeouts.Report)
+	defer done(&err)
+
+	query := `
+		SELECT ordertype, currency, COUNT(*), SUM(amount), SUM(ROUND(amount*exchangerate, 2))
+		FROM orders
+		WHERE deleted_at IS NULL AND ` + inDateRange(1) + `
+		GROUP BY ordertype, currency`
+
+	from, to := dateRangeArgs(dates)
+	rows, err := c.dbPool.Query(ctx, query, from, to)
+	if err != nil {
+		return models.Breakdown{}, fmt.Errorf("error getting breakdown: %w", err)
+	}
+	defer rows.Close()
+
+	var cells []models.BreakdownCell
+	for rows.Next() {
+		var cell models.BreakdownCell
+		if err = rows.Scan(&cell.Type, &cell.Currency, &cell.Orders, &cell.Amount, &cell.AmountUah); err != nil {
+			return models.Breakdown{}, fmt.Errorf("error getting breakdown: %w", err)
+		}
+		cells = append(cells, cell)
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.Breakdown{}, fmt.Errorf("error getting breakdown: %w", err)
 	}
 
-	return stats, nil
+	return models.NewBreakdown(cells), nil
 }
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"coursework/internal/models"
+	"coursework/internal/storage"
+	"coursework/internal/validation"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (c *DbController) OrderTypes(ctx context.Context, all bool) (_ []models.OrderType, err error) {
+	ctx, done := c.operation(ctx, "listing order types", c.timeouts.Query)
+	defer done(&err)
+
+	const query = `SELECT code, name_uk, name_en, active
+		FROM order_types
+		WHERE active OR $1
+		ORDER BY code`
+
+	rows, err := c.dbPool.Query(ctx, query, all)
+	if err != nil {
+		return nil, fmt.Errorf("error listing order types: %w", err)
+	}
+	types, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.OrderType, error) {
+		var t models.OrderType
+		err := row.Scan(&t.Code, &t.NameUk, &t.NameEn, &t.Active)
+		return t, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing order types: %w", err)
+	}
+
+	return types, nil
+}
+
+func (c *DbController) AddOrderType(ctx context.Context, orderType models.OrderType) (err error) {
+	ctx, done := c.operation(ctx, "adding order type", c.timeouts.Query)
+	defer done(&err)
+
+	const query = `INSERT INTO order_types (code, name_uk, name_en)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (code) DO NOTHING`
+
+	if err := validation.OrderType(orderType); err != nil {
+		return fmt.Errorf("error adding order type: %w", err)
+	}
+
+	tag, err := c.dbPool.Exec(ctx, query, orderType.Code,
+		strings.TrimSpace(orderType.NameUk), strings.TrimSpace(orderType.NameEn))
+	if err != nil {
+		return fmt.Errorf("error adding order type: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("error adding order type: %q already exists: %w", orderType.Code, storage.ErrConflict)
+	}
+
+	return nil
+}
+
+func (c *DbController) RenameOrderType(ctx context.Context, code, nameUk, nameEn string) (err error) {
+	ctx, done := c.operation(ctx, "renaming order type", c.timeouts.Query)
+	defer done(&err)
+
+	const query = `UPDATE order_types
+		SET name_uk = COALESCE(NULLIF($2, ''), name_uk),
+			name_en = COALESCE(NULLIF($3, ''), name_en)
+		WHERE code = $1`
+
+	if err := validation.Rename(nameUk, nameEn); err != nil {
+		return fmt.Errorf("error renaming order type: %w", err)
+	}
+
+	tag, err := c.dbPool.Exec(ctx, query, code, strings.TrimSpace(nameUk), strings.TrimSpace(nameEn))
+	if err != nil {
+		return fmt.Errorf("error renaming order type: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("error renaming order type: %q: %w", code, storage.ErrOrderTypeNotFound)
+	}
+
+	return nil
+}
+
+// DeactivateOrderType hides the type from new orders. Existing orders keep
+// it, so it stays in the table.
+func (c *DbController) DeactivateOrderType(ctx context.Context, code string) (err error) {
+	ctx, done := c.operation(ctx, "deactivating order type", c.timeouts.Query)
+	defer done(&err)
+
+	const query = `UPDATE order_types SET active = FALSE WHERE code = $1`
+
+	tag, err := c.dbPool.Exec(ctx, query, code)
+	if err != nil {
+		return fmt.Errorf("error deactivating order type: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("error deactivating order type: %q: %w", code, storage.ErrOrderTypeNotFound)
+	}
+
+	return nil
+}
+
+// querier runs queries on the pool or inside a transaction.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// activeTypes returns the codes new and changed orders may use.
+func activeTypes(ctx context.Context, q querier) (map[string]bool, error) {
+	const query = `SELECT code FROM order_types WHERE active`
+
+	rows, err := q.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read order types: %w", err)
+	}
+
+	active := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		active[code] = true
+	}
+	return active, nil
+}
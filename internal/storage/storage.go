@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"coursework/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrOrderNotFound is returned when an order with the requested id doesn't exist.
+	ErrOrderNotFound = errors.New("order is not found")
+
+	// ErrRateNotFound is returned when no official exchange rate was in effect
+	// for a currency at the requested time.
+	ErrRateNotFound = errors.New("exchange rate is not found")
+
+	// ErrOrderTypeNotFound is returned when the catalogue has no type with
+	// the requested code.
+	ErrOrderTypeNotFound = errors.New("order type is not found")
+
+	// ErrConflict is returned when a change contradicts the current state of
+	// the data, e.g. deleting an order that is already deleted.
+	ErrConflict = errors.New("conflicts with the current state")
+)
+
+// FieldError is one invalid field of a call.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned when a call is rejected because of its
+// arguments. Fields lists every invalid one.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		fields[i] = f.Error()
+	}
+	return "invalid " + strings.Join(fields, "; ")
+}
+
+// Invalid returns a ValidationError of a single field.
+func Invalid(field, format string, args ...any) error {
+	var v ValidationError
+	v.Add(field, format, args...)
+	return &v
+}
+
+// Add records an invalid field.
+func (e *ValidationError) Add(field, format string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns e if any field is invalid and nil otherwise.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// TimeoutError is returned when an operation runs out of its time limit.
+// Op names the operation, as in "getting order".
+type TimeoutError struct {
+	Op      string
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s", e.Op, e.Timeout)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Store is the full backend used by the menu, the CLI and the HTTP API. It is
+// implemented by postgres.DbController and by the in-memory memory.Store.
+type Store interface {
+	OrderStore
+	TypeStore
+	RateStore
+	HistoryStore
+}
+
+// OrderStore covers the orders and the reports built on them.
+type OrderStore interface {
+	// Location is the business time zone. Orders are returned in it and the
+	// reports group them by its days.
+	Location() *time.Location
+	SelectAllOrders(ctx context.Context, limit int) ([]models.Order, error)
+	// ListOrders returns one page of the orders matching query.Filter, sorted
+	// by query.Sort and then by id.
+	ListOrders(ctx context.Context, query models.OrderQuery) (models.OrderPage, error)
+	GetOrder(ctx context.Context, orderId int) (models.Order, error)
+	// AddNewOrder stores a new order. A zero exchangerate means the official
+	// rate in effect at orderDate is used, see RateStore.
+	AddNewOrder(ctx context.Context, orderDate time.Time, orderType string, amount decimal.Decimal, currency string, exchangerate decimal.Decimal) error
+	// ValidateOrder checks a complete order against the rules the store
+	// applies to every new and changed order, without storing it.
+	ValidateOrder(ctx context.Context, order models.Order) error
+	// ImportOrders adds all the orders in a single transaction, or none of
+	// them. Their ids are ignored and their exchange rates must be set.
+	ImportOrders(ctx context.Context, orders []models.Order) (int, error)
+	UpdateOrder(ctx context.Context, orderId int, orderType string) error
+	// PatchOrder changes the fields set in patch in a single statement.
+	PatchOrder(ctx context.Context, orderId int, patch models.OrderPatch) error
+	// DeleteOrder soft-deletes the order, hiding it from every other method.
+	DeleteOrder(ctx context.Context, orderId int) error
+	// DeletedOrders lists soft-deleted orders, most recently deleted first.
+	DeletedOrders(ctx context.Context) ([]models.Order, error)
+	RestoreOrder(ctx context.Context, orderId int) error
+	// PurgeDeletedOrders removes for good the orders deleted before the given
+	// time and returns how many were removed.
+	PurgeDeletedOrders(ctx context.Context, deletedBefore time.Time) (int, error)
+
+	// The reports below only look at orders placed within dates.
+	DatesWithBiggestOrders(ctx context.Context, limit int, dates models.DateRange) ([]models.BiggestOrders, error)
+	TypeOfSmallestOrders(ctx context.Context, limit int, dates models.DateRange) ([]string, error)
+	OrdersWhenRateChanged(ctx context.Context, dates models.DateRange) ([]models.Order, error)
+	// MonthlyDistribution counts the orders of exactly orderType under
+	// lessThen in UAH per month, see models.NewMonthlyDistribution.
+	MonthlyDistribution(ctx context.Context, orderType string, lessThen decimal.Decimal, dates models.DateRange) (models.MonthlyDistribution, error)
+	GetTableForPeriods(ctx context.Context, buckets models.Buckets, dates models.DateRange) ([]models.PeriodStats, error)
+	// RevenueSeries returns the revenue of every period between the first
+	// and the last one, see models.RevenueSeries.
+	RevenueSeries(ctx context.Context, granularity models.Granularity, dates models.DateRange) ([]models.RevenuePoint, error)
+	// Breakdown sums the orders by type and currency.
+	Breakdown(ctx context.Context, dates models.DateRange) (models.Breakdown, error)
+}
+
+// TypeStore manages the catalogue of order types. New and changed orders
+// must use the code of an active type.
+type TypeStore interface {
+	// OrderTypes lists the catalogue sorted by code, inactive types only if
+	// all is set.
+	OrderTypes(ctx context.Context, all bool) ([]models.OrderType, error)
+	// AddOrderType adds an active type. Taking the code of an existing type
+	// is an ErrConflict.
+	AddOrderType(ctx context.Context, orderType models.OrderType) error
+	// RenameOrderType changes the display names of the type, an empty name
+	// is kept as it is. The code, which orders refer to, never changes.
+	RenameOrderType(ctx context.Context, code, nameUk, nameEn string) error
+	// DeactivateOrderType keeps the type on existing orders but rejects it
+	// in new and changed ones.
+	DeactivateOrderType(ctx context.Context, code string) error
+}
+
+// RateStore keeps the official exchange rate history.
+type RateStore interface {
+	// AddRates inserts rates, replacing ones with the same currency and
+	// effective time, and returns how many rows were written.
+	AddRates(ctx context.Context, rates []models.ExchangeRate) (int, error)
+	// RateAt returns the rate of currency in effect at the given time.
+	RateAt(ctx context.Context, currency string, at time.Time) (decimal.Decimal, error)
+	// OrdersWhenOfficialRateChanged returns orders placed within dates on
+	// days when the official rate of their currency changed.
+	OrdersWhenOfficialRateChanged(ctx context.Context, dates models.DateRange) ([]models.Order, error)
+}
+
+// HistoryStore reads the audit log written alongside every order mutation.
+type HistoryStore interface {
+	// OrderHistory returns every change of the order, oldest first.
+	OrderHistory(ctx context.Context, orderId int) ([]models.OrderChange, error)
+	// ChangesBetween returns changes of all orders made in [from, to), oldest first.
+	ChangesBetween(ctx context.Context, from, to time.Time) ([]models.OrderChange, error)
+}
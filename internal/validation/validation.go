@@ -0,0 +1,268 @@
+// Package validation checks orders against the business rules before they
+// are stored. Every write path goes through a store, which rejects invalid
+// orders with a storage.ValidationError listing all the invalid fields.
+package validation
+
+import (
+	"coursework/internal/models"
+	"coursework/internal/storage"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+)
+
+// Band is the range an exchange rate must fall in, bounds included.
+type Band struct {
+	Min decimal.Decimal `yaml:"min"`
+	Max decimal.Decimal `yaml:"max"`
+}
+
+// Rules are the configurable checks of an order.
+type Rules struct {
+	// Currencies are the accepted currency codes.
+	Currencies []string        `yaml:"currencies"`
+	MinAmount  decimal.Decimal `yaml:"min_amount"`
+	MaxAmount  decimal.Decimal `yaml:"max_amount"`
+	// Rates are the sanity bands of the exchange rates to UAH by currency.
+	// Currencies without a band only need a positive rate.
+	Rates map[string]Band `yaml:"rates"`
+}
+
+// Limits of the NUMERIC(15, 2) amount and NUMERIC(10, 6) exchange rate
+// columns.
+var (
+	maxAmount = decimal.RequireFromString("9999999999999.99")
+	maxRate   = decimal.RequireFromString("9999.999999")
+)
+
+// Limits of the VARCHAR(50) type code and the VARCHAR(100) display names.
+const (
+	maxTypeLength = 50
+	maxNameLength = 100
+)
+
+func DefaultRules() Rules {
+	return Rules{
+		Currencies: ISO4217,
+		MinAmount:  decimal.RequireFromString("0.01"),
+		MaxAmount:  decimal.NewFromInt(10_000_000),
+		Rates: map[string]Band{
+			"UAH": {Min: decimal.NewFromInt(1), Max: decimal.NewFromInt(1)},
+			"USD": {Min: decimal.NewFromInt(1), Max: decimal.NewFromInt(100)},
+			"EUR": {Min: decimal.NewFromInt(1), Max: decimal.NewFromInt(100)},
+		},
+	}
+}
+
+// Validate checks that the rules themselves make sense, reporting all the
+// problems at once.
+func (r Rules) Validate() error {
+	var errs []error
+
+	if len(r.Currencies) == 0 {
+		errs = append(errs, errors.New("currencies: must not be empty"))
+	}
+	for _, c := range r.Currencies {
+		if !slices.Contains(ISO4217, c) {
+			errs = append(errs, fmt.Errorf("currencies: %q is not an ISO 4217 code", c))
+		}
+	}
+	if !r.MinAmount.IsPositive() {
+		errs = append(errs, fmt.Errorf("min_amount: must be positive, got %s", r.MinAmount))
+	}
+	if r.MaxAmount.LessThan(r.MinAmount) || r.MaxAmount.GreaterThan(maxAmount) {
+		errs = append(errs, fmt.Errorf("max_amount: must be from min_amount to %s, got %s", maxAmount, r.MaxAmount))
+	}
+	for currency, band := range r.Rates {
+		if !band.Min.IsPositive() || band.Max.LessThan(band.Min) || band.Max.GreaterThan(maxRate) {
+			errs = append(errs, fmt.Errorf("rates.%s: must be a positive range up to %s, got %s to %s", currency, maxRate, band.Min, band.Max))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validator checks orders against a set of rules and the active order types,
+// which the store passes in as a set of codes. A nil set accepts any type.
+type Validator struct {
+	rules      Rules
+	currencies map[string]bool
+	// now returns the current time, orders placed after it are rejected.
+	now func() time.Time
+}
+
+func New(rules Rules) *Validator {
+	v := &Validator{
+		rules:      rules,
+		currencies: make(map[string]bool, len(rules.Currencies)),
+		now:        time.Now,
+	}
+	for _, c := range rules.Currencies {
+		v.currencies[c] = true
+	}
+	return v
+}
+
+// NewOrder checks an order about to be added. A zero exchange rate is
+// allowed, the store looks up the official one.
+func (v *Validator) NewOrder(order models.Order, types map[string]bool) error {
+	var invalid storage.ValidationError
+	v.check(&invalid, "", order, types, order.ExchangeRate.IsZero())
+	return invalid.Err()
+}
+
+// Import checks the orders of an import, which must have their exchange
+// rates set. Fields are prefixed with the number of the order, counted from 1.
+func (v *Validator) Import(orders []models.Order, types map[string]bool) error {
+	var invalid storage.ValidationError
+	for i, order := range orders {
+		v.check(&invalid, fmt.Sprintf("order %d: ", i+1), order, types, false)
+	}
+	return invalid.Err()
+}
+
+// Order checks a complete order, whose exchange rate is set.
+func (v *Validator) Order(order models.Order, types map[string]bool) error {
+	var invalid storage.ValidationError
+	v.check(&invalid, "", order, types, false)
+	return invalid.Err()
+}
+
+// Rate checks the exchange rate the store looked up for an order left
+// without one, the same way an entered rate is checked.
+func (v *Validator) Rate(currency string, rate decimal.Decimal) error {
+	var invalid storage.ValidationError
+	v.checkRate(&invalid, "", currency, rate)
+	return invalid.Err()
+}
+
+// Patch checks the fields the patch changes. The rate is checked against
+// the band of the currency if either of them changes. Fields left alone
+// aren't checked, so orders that broke rules tightened since, e.g. of a
+// deactivated type, can still be corrected.
+func (v *Validator) Patch(before models.Order, patch models.OrderPatch, types map[string]bool) error {
+	if patch.IsEmpty() {
+		return storage.Invalid("patch", "nothing to change")
+	}
+
+	after := patch.Apply(before)
+	var invalid storage.ValidationError
+	if patch.TimeStamp != nil {
+		v.checkTimeStamp(&invalid, "", after.TimeStamp)
+	}
+	if patch.Type != nil {
+		checkType(&invalid, "", after.Type, types)
+	}
+	if patch.Amount != nil {
+		v.checkAmount(&invalid, "", after.Amount)
+	}
+	if patch.Currency != nil {
+		v.checkCurrency(&invalid, "", after.Currency)
+	}
+	if patch.Currency != nil || patch.ExchangeRate != nil {
+		v.checkRate(&invalid, "", after.Currency, after.ExchangeRate)
+	}
+	return invalid.Err()
+}
+
+// OrderType checks a new entry of the catalogue. Codes are kept in lower
+// case, so that types differing in case only can't be added.
+func OrderType(orderType models.OrderType) error {
+	var invalid storage.ValidationError
+
+	code := orderType.Code
+	switch {
+	case code == "" || utf8.RuneCountInString(code) > maxTypeLength:
+		invalid.Add("code", "must be 1 to %d characters, got %q", maxTypeLength, code)
+	case code != strings.ToLower(strings.TrimSpace(code)):
+		invalid.Add("code", "must be in lower case without surrounding spaces, got %q", code)
+	}
+	checkName(&invalid, "Ukrainian name", orderType.NameUk)
+	checkName(&invalid, "English name", orderType.NameEn)
+
+	return invalid.Err()
+}
+
+// Rename checks the new display names of a type, an empty one is kept.
+func Rename(nameUk, nameEn string) error {
+	if nameUk == "" && nameEn == "" {
+		return storage.Invalid("names", "nothing to change")
+	}
+
+	var invalid storage.ValidationError
+	if nameUk != "" {
+		checkName(&invalid, "Ukrainian name", nameUk)
+	}
+	if nameEn != "" {
+		checkName(&invalid, "English name", nameEn)
+	}
+	return invalid.Err()
+}
+
+func checkName(invalid *storage.ValidationError, field, name string) {
+	if strings.TrimSpace(name) == "" || utf8.RuneCountInString(name) > maxNameLength {
+		invalid.Add(field, "must be 1 to %d characters, got %q", maxNameLength, name)
+	}
+}
+
+// check adds the invalid fields of order to invalid, their names prefixed
+// with prefix. The exchange rate is skipped if it is yet to be looked up.
+func (v *Validator) check(invalid *storage.ValidationError, prefix string, order models.Order, types map[string]bool, lookUpRate bool) {
+	v.checkTimeStamp(invalid, prefix, order.TimeStamp)
+	checkType(invalid, prefix, order.Type, types)
+	v.checkAmount(invalid, prefix, order.Amount)
+	v.checkCurrency(invalid, prefix, order.Currency)
+	if !lookUpRate {
+		v.checkRate(invalid, prefix, order.Currency, order.ExchangeRate)
+	}
+}
+
+func (v *Validator) checkTimeStamp(invalid *storage.ValidationError, prefix string, timeStamp time.Time) {
+	switch {
+	case timeStamp.IsZero():
+		invalid.Add(prefix+"timestamp", "must be set")
+	case timeStamp.After(v.now()):
+		invalid.Add(prefix+"timestamp", "must not be in the future, got %s", timeStamp.Format(time.DateTime))
+	}
+}
+
+// checkType looks the type up in types unless it is nil.
+func checkType(invalid *storage.ValidationError, prefix, orderType string, types map[string]bool) {
+	switch {
+	case orderType == "" || utf8.RuneCountInString(orderType) > maxTypeLength:
+		invalid.Add(prefix+"type", "must be 1 to %d characters, got %q", maxTypeLength, orderType)
+	case types != nil && !types[orderType]:
+		invalid.Add(prefix+"type", "must be the code of an active order type, got %q", orderType)
+	}
+}
+
+func (v *Validator) checkAmount(invalid *storage.ValidationError, prefix string, amount decimal.Decimal) {
+	if amount.LessThan(v.rules.MinAmount) || amount.GreaterThan(v.rules.MaxAmount) {
+		invalid.Add(prefix+"amount", "must be from %s to %s, got %s", v.rules.MinAmount, v.rules.MaxAmount, amount)
+	}
+}
+
+func (v *Validator) checkCurrency(invalid *storage.ValidationError, prefix, currency string) {
+	if !v.currencies[currency] {
+		invalid.Add(prefix+"currency", "must be an ISO 4217 code we accept, got %q", currency)
+	}
+}
+
+// checkRate adds the exchange rate to invalid if it is outside the band of
+// currency.
+func (v *Validator) checkRate(invalid *storage.ValidationError, prefix, currency string, rate decimal.Decimal) {
+	band, banded := v.rules.Rates[currency]
+	switch {
+	case !rate.IsPositive() || rate.GreaterThan(maxRate):
+		invalid.Add(prefix+"exchange rate", "must be positive and at most %s, got %s", maxRate, rate)
+	case banded && band.Min.Equal(band.Max) && !rate.Equal(band.Min):
+		invalid.Add(prefix+"exchange rate", "must be %s for %s, got %s", band.Min, currency, rate)
+	case banded && (rate.LessThan(band.Min) || rate.GreaterThan(band.Max)):
+		invalid.Add(prefix+"exchange rate", "must be from %s to %s for %s, got %s", band.Min, band.Max, currency, rate)
+	}
+}
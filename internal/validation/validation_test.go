@@ -0,0 +1,238 @@
+package validation
+
+import (
+	"coursework/internal/models"
+	"coursework/internal/storage"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func newTestValidator() *Validator {
+	v := New(DefaultRules())
+	v.now = func() time.Time { return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC) }
+	return v
+}
+
+// activeTypes are the order types the tests accept.
+var activeTypes = map[string]bool{"харчування": true, "одяг": true}
+
+func validOrder() models.Order {
+	return models.Order{
+		TimeStamp:    time.Date(2025, 12, 1, 9, 30, 0, 0, time.UTC),
+		Type:         "харчування",
+		Amount:       decimal.NewFromInt(20),
+		Currency:     "USD",
+		ExchangeRate: decimal.RequireFromString("41.2"),
+	}
+}
+
+// invalidFields returns the names of the invalid fields in err, joined by
+// commas.
+func invalidFields(t *testing.T, err error) string {
+	t.Helper()
+
+	var invalid *storage.ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("got %v, want a validation error", err)
+	}
+	var fields []string
+	for _, f := range invalid.Fields {
+		fields = append(fields, f.Field)
+	}
+	return strings.Join(fields, ",")
+}
+
+func TestNewOrder(t *testing.T) {
+	v := newTestValidator()
+
+	t.Run("valid order", func(t *testing.T) {
+		if err := v.NewOrder(validOrder(), activeTypes); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("rate is looked up", func(t *testing.T) {
+		order := validOrder()
+		order.ExchangeRate = decimal.Zero
+		if err := v.NewOrder(order, activeTypes); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("reports every field", func(t *testing.T) {
+		order := models.Order{
+			TimeStamp:    time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+			Type:         "подарунки",
+			Amount:       decimal.NewFromInt(-5),
+			Currency:     "XYZ",
+			ExchangeRate: decimal.NewFromInt(-1),
+		}
+
+		got := invalidFields(t, v.NewOrder(order, activeTypes))
+		if want := "timestamp,type,amount,currency,exchange rate"; got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	})
+
+	cases := []struct {
+		name   string
+		change func(o *models.Order)
+		want   string
+	}{
+		{"type too long", func(o *models.Order) { o.Type = strings.Repeat("я", 51) }, "type"},
+		{"currency too long", func(o *models.Order) { o.Currency = "USDT" }, "currency"},
+		{"amount over the maximum", func(o *models.Order) { o.Amount = decimal.NewFromInt(10_000_001) }, "amount"},
+		{"UAH rate other than 1", func(o *models.Order) { o.Currency, o.ExchangeRate = "UAH", decimal.NewFromInt(2) }, "exchange rate"},
+		{"rate outside the band", func(o *models.Order) { o.ExchangeRate = decimal.NewFromInt(4120) }, "exchange rate"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			order := validOrder()
+			c.change(&order)
+			if got := invalidFields(t, v.NewOrder(order, activeTypes)); got != c.want {
+				t.Errorf("got %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	order := validOrder()
+	order.ExchangeRate = decimal.Zero
+
+	err := newTestValidator().Import([]models.Order{validOrder(), order}, activeTypes)
+	if got, want := invalidFields(t, err), "order 2: exchange rate"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestRate(t *testing.T) {
+	v := newTestValidator()
+
+	if err := v.Rate("USD", decimal.RequireFromString("41.2")); err != nil {
+		t.Error(err)
+	}
+	if got := invalidFields(t, v.Rate("USD", decimal.NewFromInt(4120))); got != "exchange rate" {
+		t.Errorf("got %s, want exchange rate", got)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	v := newTestValidator()
+
+	t.Run("empty patch", func(t *testing.T) {
+		if got := invalidFields(t, v.Patch(validOrder(), models.OrderPatch{}, activeTypes)); got != "patch" {
+			t.Errorf("got %s, want patch", got)
+		}
+	})
+
+	t.Run("checks the patched order", func(t *testing.T) {
+		// the USD rate doesn't suit UAH
+		currency := "UAH"
+		if got := invalidFields(t, v.Patch(validOrder(), models.OrderPatch{Currency: &currency}, activeTypes)); got != "exchange rate" {
+			t.Errorf("got %s, want exchange rate", got)
+		}
+	})
+
+	t.Run("valid patch", func(t *testing.T) {
+		amount := decimal.RequireFromString("10.50")
+		if err := v.Patch(validOrder(), models.OrderPatch{Amount: &amount}, activeTypes); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("keeping fields outside the current rules", func(t *testing.T) {
+		// placed in the future and at a rate outside today's band
+		before := validOrder()
+		before.TimeStamp = time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+		before.ExchangeRate = decimal.NewFromInt(4120)
+
+		amount := decimal.RequireFromString("10.50")
+		if err := v.Patch(before, models.OrderPatch{Amount: &amount}, activeTypes); err != nil {
+			t.Error(err)
+		}
+
+		rate := decimal.NewFromInt(4000)
+		if got := invalidFields(t, v.Patch(before, models.OrderPatch{ExchangeRate: &rate}, activeTypes)); got != "exchange rate" {
+			t.Errorf("got %s, want exchange rate", got)
+		}
+	})
+
+	t.Run("keeping a deactivated type", func(t *testing.T) {
+		amount := decimal.RequireFromString("10.50")
+		if err := v.Patch(validOrder(), models.OrderPatch{Amount: &amount}, map[string]bool{}); err != nil {
+			t.Error(err)
+		}
+
+		orderType := "харчування"
+		if got := invalidFields(t, v.Patch(validOrder(), models.OrderPatch{Type: &orderType}, map[string]bool{})); got != "type" {
+			t.Errorf("got %s, want type", got)
+		}
+	})
+}
+
+func TestOrderType(t *testing.T) {
+	cases := []struct {
+		name      string
+		orderType models.OrderType
+		want      string
+	}{
+		{"valid type", models.OrderType{Code: "подарунки", NameUk: "Подарунки", NameEn: "Gifts"}, ""},
+		{"code in upper case", models.OrderType{Code: "Подарунки", NameUk: "Подарунки", NameEn: "Gifts"}, "code"},
+		{"code with spaces", models.OrderType{Code: " подарунки", NameUk: "Подарунки", NameEn: "Gifts"}, "code"},
+		{"missing names", models.OrderType{Code: "подарунки", NameUk: " "}, "Ukrainian name,English name"},
+		{"name too long", models.OrderType{Code: "подарунки", NameUk: strings.Repeat("я", 101), NameEn: "Gifts"}, "Ukrainian name"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := OrderType(c.orderType)
+			if c.want == "" {
+				if err != nil {
+					t.Error(err)
+				}
+				return
+			}
+			if got := invalidFields(t, err); got != c.want {
+				t.Errorf("got %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	if got := invalidFields(t, Rename("", "")); got != "names" {
+		t.Errorf("got %s, want names", got)
+	}
+	if err := Rename("", "Groceries"); err != nil {
+		t.Error(err)
+	}
+	if got := invalidFields(t, Rename("  ", "Groceries")); got != "Ukrainian name" {
+		t.Errorf("got %s, want Ukrainian name", got)
+	}
+}
+
+func TestRulesValidate(t *testing.T) {
+	if err := DefaultRules().Validate(); err != nil {
+		t.Errorf("default rules: %v", err)
+	}
+
+	rules := DefaultRules()
+	rules.Currencies = []string{"USD", "XYZ"}
+	rules.MinAmount = decimal.NewFromInt(100)
+	rules.MaxAmount = decimal.NewFromInt(10)
+	rules.Rates["USD"] = Band{Min: decimal.NewFromInt(50), Max: decimal.NewFromInt(40)}
+
+	err := rules.Validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"XYZ", "max_amount", "rates.USD"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v, want it to mention %s", err, want)
+		}
+	}
+}
@@ -1,17 +1,43 @@
 package main
 
 import (
+	"context"
 	"coursework/internal/app"
+	"coursework/internal/cli"
+	"coursework/internal/config"
+	"coursework/internal/frontend"
+	"coursework/internal/memory"
 	"coursework/internal/postgres"
-	"log"
+	"coursework/internal/storage"
+	"coursework/internal/validation"
+	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
 	"os"
-
-	"github.com/joho/godotenv"
+	"os/signal"
+	"os/user"
+	"syscall"
 )
 
 func main() {
-	cleanup, err := app.StartupLogger()
+	os.Exit(run())
+}
+
+func run() int {
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return cli.ExitUsage
+	}
+
+	renderer, err := frontend.NewRenderer(cfg.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return cli.ExitUsage
+	}
+
+	cleanup, err := app.StartupLogger(cfg.LogFile)
 	if err != nil {
 		panic(err)
 	}
@@ -19,19 +45,74 @@ func main() {
 
 	slog.Info("Logger initialized")
 
-	_ = godotenv.Load()
-	dbURL := os.Getenv("DB_URL")
+	// The first interrupt cancels the running call and the menu, a second
+	// one kills the process as usual.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-	slog.Info("Loaded environment variables")
+	actor := currentUser()
+	validator := validation.New(cfg.Validation)
 
-	controller := postgres.NewDbController(dbURL)
-	defer controller.Close()
+	var controller storage.Store
+	if cfg.Memory {
+		store := memory.NewStore()
+		store.SetActor(actor)
+		store.SetLocation(cfg.Location)
+		store.SetValidator(validator)
+		controller = store
 
-	slog.Info("✅ Connected to DB")
+		slog.Info("Using in-memory store")
+	} else {
+		dbController, err := postgres.NewDbController(ctx, cfg.DatabaseURL, cfg.ConnectOptions())
+		if err != nil {
+			slog.Error("Couldn't connect to DB", "err", err)
+			fmt.Fprintln(os.Stderr, err)
+			return cli.ExitFailure
+		}
+		defer dbController.Close()
+		dbController.SetActor(actor)
+		dbController.SetLocation(cfg.Location)
+		dbController.SetTimeouts(cfg.Timeouts())
+		dbController.SetValidator(validator)
+		controller = dbController
 
-	err = app.Menu(os.Stdout, os.Stdin, controller)
+		health, err := dbController.Health(ctx)
+		if err != nil {
+			slog.Error("Couldn't check DB health", "err", err)
+			fmt.Fprintln(os.Stderr, err)
+			return cli.ExitFailure
+		}
+		slog.Info("✅ Connected to DB", "version", health.ServerVersion, "latency", health.Latency)
+	}
+
+	if flag.NArg() > 0 {
+		return cli.Run(ctx, flag.Args(), os.Stdout, os.Stderr, controller, renderer, cfg.Reports)
+	}
 
+	err = app.Menu(ctx, os.Stdout, os.Stdin, controller, renderer, cfg.Reports)
+	if errors.Is(err, context.Canceled) {
+		slog.Info("Interrupted")
+		return cli.ExitInterrupted
+	}
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintln(os.Stderr, err)
+		return cli.ExitFailure
+	}
+
+	return cli.ExitOK
+}
+
+// currentUser names the operator recorded in the order history.
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
 	}
+	return "unknown"
 }
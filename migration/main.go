@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"coursework/internal/config"
+	"coursework/internal/migrate"
+	"coursework/internal/postgres"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const usage = `Usage: migration [flags] command
+
+Commands:
+  migration up        apply all pending migrations
+  migration down [N]  roll back the last N migrations (default 1)
+  migration status    list migrations and when they were applied
+  migration redo      roll back the last migration and apply it again
+
+Migrations converting stored times read them in the business time zone
+(TIME_ZONE / -tz), so run them with the zone the application uses.
+Rolling back soft_delete_orders fails while deleted orders exist, purge or
+restore them first.
+`
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("migration", flag.ContinueOnError)
+	cfg, err := config.Load(flags, args)
+	if err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("missing command")
+	}
+
+	ctx := context.Background()
+	dbPool, err := postgres.Connect(ctx, cfg.DatabaseURL, cfg.ConnectOptions())
+	if err != nil {
+		return err
+	}
+	defer dbPool.Close()
+
+	migrator, err := migrate.NewMigrator(dbPool, cfg.TimeZone)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %s\n", m)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("nothing to migrate")
+		}
+		return err
+
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			n, err = strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("down expects a positive number of migrations, got %q", args[1])
+			}
+		}
+
+		rolledBack, err := migrator.Down(ctx, n)
+		for _, m := range rolledBack {
+			fmt.Printf("rolled back %s\n", m)
+		}
+		return err
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-40s %s\n", s.Migration, applied)
+		}
+		return nil
+
+	case "redo":
+		m, err := migrator.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("redone %s\n", m)
+		return nil
+	}
+
+	fmt.Fprint(os.Stderr, usage)
+	return fmt.Errorf("unknown command %q", args[0])
+}